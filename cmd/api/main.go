@@ -1,40 +1,315 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Gab-Mello/service-finder/internal/audit"
 	"github.com/Gab-Mello/service-finder/internal/auth"
 	transport "github.com/Gab-Mello/service-finder/internal/http"
+	"github.com/Gab-Mello/service-finder/internal/mail"
+	"github.com/Gab-Mello/service-finder/internal/oidc"
 	"github.com/Gab-Mello/service-finder/internal/order"
+	"github.com/Gab-Mello/service-finder/internal/ports"
 	"github.com/Gab-Mello/service-finder/internal/posting"
 	"github.com/Gab-Mello/service-finder/internal/review"
+	"github.com/Gab-Mello/service-finder/internal/storage/sqlite"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
 	"github.com/Gab-Mello/service-finder/internal/user"
 
 	_ "github.com/Gab-Mello/service-finder/docs"
 )
 
+type repositories struct {
+	users      user.Repository
+	userTokens user.TokenRepository
+	postings   posting.Repository
+	orders     order.Repository
+	reviews    review.Repository
+	identities user.IdentityRepository
+	apiKeys    user.APIKeyRepository
+	sessions   auth.SessionStore
+	attempts   auth.AttemptStore
+	audit      audit.Repository
+}
+
 func main() {
 	addr := ":8080"
 
-	sessions := auth.NewSessionManager(5 * time.Minute)
+	repos, closeRepos, err := openRepositories(getenv("STORAGE", "memory"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeRepos()
+
+	defaults := auth.DefaultSessionOptions()
+	sessions := auth.NewSessionManager(repos.sessions, auth.SessionOptions{
+		IdleTimeout:         getenvDuration("SESSION_IDLE_TIMEOUT", defaults.IdleTimeout),
+		MaxLifetime:         getenvDuration("SESSION_MAX_LIFETIME", defaults.MaxLifetime),
+		RememberIdleTimeout: getenvDuration("SESSION_REMEMBER_IDLE_TIMEOUT", defaults.RememberIdleTimeout),
+		RememberMaxLifetime: getenvDuration("SESSION_REMEMBER_MAX_LIFETIME", defaults.RememberMaxLifetime),
+		AccessTokenTTL:      getenvDuration("ACCESS_TOKEN_TTL", defaults.AccessTokenTTL),
+		RefreshTokenTTL:     getenvDuration("REFRESH_TOKEN_TTL", defaults.RefreshTokenTTL),
+		ImpersonationTTL:    getenvDuration("IMPERSONATION_TTL", defaults.ImpersonationTTL),
+	})
+	defer sessions.Close()
+	if key := os.Getenv("CSRF_SECRET"); key != "" {
+		sessions.SetCSRFKey([]byte(key))
+	}
+
+	userRepo := repos.users
+	hasher, err := auth.NewBcryptHasher(getenvInt("BCRYPT_COST", 0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	userSvc := user.NewService(userRepo, hasher, time.Now, nil)
+	userSvc.SetSessionRevoker(sessions)
+	auditLog := audit.NewLog(repos.audit, time.Now, nil)
+	userSvc.SetAuditLog(auditLog)
+
+	lockout := auth.DefaultLockoutOptions()
+	userSvc.SetLoginThrottle(auth.NewLoginGuard(repos.attempts, auth.LockoutOptions{
+		AccountThreshold: getenvInt("LOGIN_MAX_FAILURES", lockout.AccountThreshold),
+		IPThreshold:      getenvInt("LOGIN_MAX_FAILURES_PER_IP", lockout.IPThreshold),
+		BaseLockout:      getenvDuration("LOGIN_LOCKOUT", lockout.BaseLockout),
+		MaxLockout:       getenvDuration("LOGIN_MAX_LOCKOUT", lockout.MaxLockout),
+		ResetAfter:       lockout.ResetAfter,
+	}))
+
+	mailer, err := openMailer(getenv("MAILER", "outbox"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	appURL := getenv("APP_URL", "http://localhost:5173")
+	userSvc.SetMailer(mailer, repos.userTokens, appURL)
+	userSvc.SetIdentityRepository(repos.identities)
+	userSvc.SetAPIKeyRepository(repos.apiKeys)
 
-	userRepo := user.NewRepository()
-	userSvc := user.NewService(userRepo, nil, time.Now, nil)
+	oidcClient, err := openOIDC(getenv("OIDC_PROVIDERS", ""), getenv("PUBLIC_URL", "http://localhost:8080"))
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	postRepo := posting.NewRepository()
+	if email := os.Getenv("ADMIN_EMAIL"); email != "" {
+		if _, err := userSvc.EnsureAdmin(email, os.Getenv("ADMIN_PASSWORD")); err != nil {
+			log.Fatalf("failed to set up admin account: %v", err)
+		}
+	}
 
-	orderRepo := order.NewRepository()
+	postRepo := repos.postings
+
+	orderRepo := repos.orders
 	orderSvc := order.NewService(orderRepo, time.Now, nil, nil)
+	orderSvc.SetAddressBook(userSvc)
 
-	reviewRepo := review.NewRepository()
+	reviewRepo := repos.reviews
 	reviewSvc := review.NewService(reviewRepo, orderRepo, time.Now)
 
 	postSvc := posting.NewService(postRepo, userSvc, time.Now, nil, reviewSvc)
+	userSvc.SetProviderPostings(postSvc)
+	postSvc.RequireVerifiedProviders(getenv("REQUIRE_VERIFIED_PROVIDERS", "true") == "true")
+
+	// reviews are found through the user's orders, so they are anonymized
+	// before the orders
+	userSvc.AddPersonalData("reviews", reviewSvc)
+	userSvc.AddPersonalData("orders", orderSvc)
+	userSvc.AddPersonalData("postings", postSvc)
 
 	mux := transport.NewServer()
-	transport.RegisterAll(mux, sessions, userSvc, postSvc, orderSvc, reviewSvc)
+	transport.RegisterAll(mux, sessions, userSvc, postSvc, orderSvc, reviewSvc, auditLog, oidcClient, appURL)
 
 	log.Printf("listening on %s", addr)
-	log.Fatal(transport.Listen(addr, mux))
+	log.Fatal(transport.Listen(addr, mux, sessions, auditLog))
+}
+
+func openRepositories(storage string) (repositories, func(), error) {
+	switch storage {
+	case "sqlite":
+		path := getenv("SQLITE_PATH", "service-finder.db")
+		db, err := sqlite.Open(path)
+		if err != nil {
+			return repositories{}, nil, err
+		}
+		log.Printf("using sqlite storage at %s", path)
+		return repositories{
+			users:      sqlite.NewUserRepository(db),
+			userTokens: sqlite.NewTokenRepository(db),
+			identities: sqlite.NewIdentityRepository(db),
+			apiKeys:    sqlite.NewAPIKeyRepository(db),
+			postings:   sqlite.NewPostingRepository(db),
+			orders:     sqlite.NewOrderRepository(db),
+			reviews:    sqlite.NewReviewRepository(db),
+			sessions:   sqlite.NewSessionStore(db),
+			attempts:   sqlite.NewAttemptStore(db),
+			audit:      sqlite.NewAuditRepository(db),
+		}, func() { db.Close() }, nil
+	case "wal":
+		return openDurableRepositories(getenv("DATA_DIR", "data"), wal.Options{
+			Recover: os.Getenv("WAL_RECOVER") == "true",
+		})
+	case "memory":
+		return repositories{
+			users:      user.NewRepository(),
+			userTokens: user.NewTokenRepository(),
+			identities: user.NewIdentityRepository(),
+			apiKeys:    user.NewAPIKeyRepository(),
+			postings:   posting.NewRepository(),
+			orders:     order.NewRepository(),
+			reviews:    review.NewRepository(),
+			sessions:   auth.NewMemorySessionStore(),
+			attempts:   auth.NewMemoryAttemptStore(),
+			audit:      audit.NewRepository(),
+		}, func() {}, nil
+	default:
+		return repositories{}, nil, fmt.Errorf("unknown STORAGE %q (expected memory, wal or sqlite)", storage)
+	}
+}
+
+func openDurableRepositories(dir string, opts wal.Options) (repositories, func(), error) {
+	logs := make(map[string]*wal.Log)
+	closeLogs := func() {
+		for _, l := range logs {
+			l.Close()
+		}
+	}
+	for _, name := range []string{"users", "user_tokens", "user_identities", "api_keys", "postings", "orders", "reviews", "sessions", "login_attempts", "audit"} {
+		l, err := wal.Open(dir, name, opts)
+		if err != nil {
+			closeLogs()
+			return repositories{}, nil, err
+		}
+		logs[name] = l
+	}
+
+	var (
+		repos repositories
+		err   error
+	)
+	if repos.users, err = user.NewDurableRepository(logs["users"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	if repos.userTokens, err = user.NewDurableTokenRepository(logs["user_tokens"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	if repos.identities, err = user.NewDurableIdentityRepository(logs["user_identities"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	if repos.apiKeys, err = user.NewDurableAPIKeyRepository(logs["api_keys"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	if repos.postings, err = posting.NewDurableRepository(logs["postings"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	if repos.orders, err = order.NewDurableRepository(logs["orders"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	if repos.reviews, err = review.NewDurableRepository(logs["reviews"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	if repos.sessions, err = auth.NewDurableSessionStore(logs["sessions"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	if repos.attempts, err = auth.NewDurableAttemptStore(logs["login_attempts"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	if repos.audit, err = audit.NewDurableRepository(logs["audit"]); err != nil {
+		closeLogs()
+		return repositories{}, nil, err
+	}
+	log.Printf("using wal storage in %s", dir)
+	return repos, closeLogs, nil
+}
+
+// openOIDC configures the comma-separated identity providers in names from
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID and OIDC_<NAME>_CLIENT_SECRET.
+// It returns nil when none are configured.
+func openOIDC(names, publicURL string) (*oidc.Client, error) {
+	var providers []*oidc.Provider
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		p, err := oidc.NewProvider(context.Background(), oidc.Config{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  strings.TrimRight(publicURL, "/") + "/api/v1/auth/oidc/" + name + "/callback",
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("oidc login enabled for %s", name)
+		providers = append(providers, p)
+	}
+	if len(providers) == 0 {
+		return nil, nil
+	}
+	return oidc.NewClient(providers...), nil
+}
+
+func openMailer(kind string) (ports.Mailer, error) {
+	from := getenv("MAIL_FROM", "Service Finder <no-reply@localhost>")
+	switch kind {
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Addr:     os.Getenv("SMTP_ADDR"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		})
+	case "outbox":
+		dir := getenv("OUTBOX_DIR", "outbox")
+		log.Printf("writing outgoing mail to %s", dir)
+		return mail.NewOutbox(dir, from)
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q (expected outbox or smtp)", kind)
+	}
+}
+
+func getenv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func getenvInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return n
+}
+
+func getenvDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("invalid %s: %q", key, v)
+	}
+	return d
 }
@@ -0,0 +1,105 @@
+// Package audit keeps an append-only trail of administrative actions.
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// Entry records that ActorID performed Action on TargetID.
+type Entry struct {
+	ID       string            `json:"id"`
+	At       time.Time         `json:"at"`
+	ActorID  string            `json:"actorId"`
+	Action   string            `json:"action"`
+	TargetID string            `json:"targetId,omitempty"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// Query selects entries; empty fields match everything.
+type Query struct {
+	ActorID  string
+	TargetID string
+	Limit    int
+}
+
+type Repository interface {
+	Append(e *Entry) error
+	// List returns matching entries, newest first.
+	List(q Query) ([]Entry, error)
+}
+
+type memoryRepo struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+func NewRepository() Repository {
+	return &memoryRepo{}
+}
+
+func (r *memoryRepo) Append(e *Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, *e)
+	return nil
+}
+
+func (r *memoryRepo) List(q Query) ([]Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Entry, 0)
+	for i := len(r.entries) - 1; i >= 0 && len(out) < q.Limit; i-- {
+		e := r.entries[i]
+		if (q.ActorID == "" || e.ActorID == q.ActorID) && (q.TargetID == "" || e.TargetID == q.TargetID) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Log records and lists audit entries.
+type Log struct {
+	repo  Repository
+	now   func() time.Time
+	idgen func() string
+}
+
+func NewLog(r Repository, now func() time.Time, idgen func() string) *Log {
+	if now == nil {
+		now = func() time.Time { return time.Now().UTC() }
+	}
+	if idgen == nil {
+		idgen = func() string { return uuid.NewString() }
+	}
+	return &Log{repo: r, now: now, idgen: idgen}
+}
+
+func (l *Log) Record(actorID, action, targetID string, details map[string]string) error {
+	return l.repo.Append(&Entry{
+		ID:       l.idgen(),
+		At:       l.now(),
+		ActorID:  actorID,
+		Action:   action,
+		TargetID: targetID,
+		Details:  details,
+	})
+}
+
+// List returns matching entries, newest first. The limit defaults to 50 and
+// is capped at 500.
+func (l *Log) List(q Query) ([]Entry, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultLimit
+	}
+	q.Limit = min(q.Limit, maxLimit)
+	return l.repo.List(q)
+}
@@ -0,0 +1,99 @@
+// Package audittest provides a conformance suite for audit.Repository
+// implementations.
+package audittest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/audit"
+)
+
+// TestRepository runs the behavior every audit.Repository must share with
+// the in-memory implementation. newRepo must return an empty repository on
+// each call.
+func TestRepository(t *testing.T, newRepo func() audit.Repository) {
+	t.Helper()
+
+	t.Run("AppendAndList", func(t *testing.T) {
+		r := newRepo()
+		e := sample(1, "admin1", "u1")
+		e.Details = map[string]string{"reason": "spam", "until": "2025-04-01T00:00:00Z"}
+		mustAppend(t, r, e)
+
+		list, err := r.List(audit.Query{Limit: 10})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(list) != 1 {
+			t.Fatalf("List: got %d entries, want 1", len(list))
+		}
+		assertEqual(t, &list[0], e)
+	})
+
+	t.Run("NewestFirst", func(t *testing.T) {
+		r := newRepo()
+		for i := 1; i <= 3; i++ {
+			mustAppend(t, r, sample(i, "admin1", "u1"))
+		}
+		assertIDs(t, r, audit.Query{Limit: 10}, "e3", "e2", "e1")
+		assertIDs(t, r, audit.Query{Limit: 2}, "e3", "e2")
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		r := newRepo()
+		mustAppend(t, r, sample(1, "admin1", "u1"))
+		mustAppend(t, r, sample(2, "admin2", "u1"))
+		mustAppend(t, r, sample(3, "admin1", "u2"))
+
+		assertIDs(t, r, audit.Query{ActorID: "admin1", Limit: 10}, "e3", "e1")
+		assertIDs(t, r, audit.Query{TargetID: "u1", Limit: 10}, "e2", "e1")
+		assertIDs(t, r, audit.Query{ActorID: "admin1", TargetID: "u1", Limit: 10}, "e1")
+		assertIDs(t, r, audit.Query{ActorID: "nobody", Limit: 10})
+	})
+}
+
+func sample(n int, actorID, targetID string) *audit.Entry {
+	return &audit.Entry{
+		ID:       fmt.Sprintf("e%d", n),
+		At:       time.Date(2025, 3, 10, 12, n, 0, 0, time.UTC),
+		ActorID:  actorID,
+		Action:   "user.suspend",
+		TargetID: targetID,
+	}
+}
+
+func mustAppend(t *testing.T, r audit.Repository, e *audit.Entry) {
+	t.Helper()
+	if err := r.Append(e); err != nil {
+		t.Fatalf("Append %s: %v", e.ID, err)
+	}
+}
+
+func assertIDs(t *testing.T, r audit.Repository, q audit.Query, want ...string) {
+	t.Helper()
+	list, err := r.List(q)
+	if err != nil {
+		t.Fatalf("List %+v: %v", q, err)
+	}
+	if list == nil {
+		t.Fatalf("List %+v: got nil, want non-nil slice", q)
+	}
+	got := make([]string, 0, len(list))
+	for _, e := range list {
+		got = append(got, e.ID)
+	}
+	if !reflect.DeepEqual(got, append([]string{}, want...)) {
+		t.Fatalf("List %+v: got %v, want %v", q, got, want)
+	}
+}
+
+func assertEqual(t *testing.T, got, want *audit.Entry) {
+	t.Helper()
+	if got.ID != want.ID || !got.At.Equal(want.At) || got.ActorID != want.ActorID ||
+		got.Action != want.Action || got.TargetID != want.TargetID || !reflect.DeepEqual(got.Details, want.Details) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
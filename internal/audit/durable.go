@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/durable"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
+)
+
+const opAppend = "append"
+
+type durableRepo struct {
+	*memoryRepo
+	log *durable.Log[Entry]
+}
+
+func NewDurableRepository(w *wal.Log) (Repository, error) {
+	m := NewRepository().(*memoryRepo)
+	l, err := durable.Open(w, "audit log", m.all,
+		func(all []Entry) error {
+			m.entries = all
+			return nil
+		},
+		func(op string, dec wal.Decoder) error {
+			if op != opAppend {
+				return fmt.Errorf("unknown operation %q", op)
+			}
+			var e Entry
+			if err := dec(&e); err != nil {
+				return err
+			}
+			return m.Append(&e)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &durableRepo{memoryRepo: m, log: l}, nil
+}
+
+func (r *durableRepo) Append(e *Entry) error {
+	return r.log.Do(opAppend, e, nil, func() error { return r.memoryRepo.Append(e) })
+}
+
+func (r *memoryRepo) all() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Entry(nil), r.entries...)
+}
@@ -0,0 +1,18 @@
+package audit_test
+
+import (
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/audit"
+	"github.com/Gab-Mello/service-finder/internal/audit/audittest"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal/waltest"
+)
+
+func TestRepository(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		audittest.TestRepository(t, audit.NewRepository)
+	})
+	t.Run("Durable", func(t *testing.T) {
+		audittest.TestRepository(t, waltest.Repository(t, "audit", audit.NewDurableRepository))
+	})
+}
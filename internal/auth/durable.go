@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/durable"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
+)
+
+const opDeleteExpired = "delete_expired"
+
+// durableSessionStore logs every session mutation so sessions survive a
+// restart, mirroring the durable repositories of the domain packages.
+type durableSessionStore struct {
+	*memorySessionStore
+	log *durable.Log[Session]
+}
+
+func NewDurableSessionStore(w *wal.Log) (SessionStore, error) {
+	m := NewMemorySessionStore().(*memorySessionStore)
+	l, err := durable.Open(w, "sessions", m.all,
+		func(all []Session) error {
+			for i := range all {
+				if err := m.Create(&all[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(op string, dec wal.Decoder) error {
+			switch op {
+			case durable.OpCreate, durable.OpUpdate:
+				var s Session
+				if err := dec(&s); err != nil {
+					return err
+				}
+				return m.Create(&s)
+			case durable.OpDelete:
+				var id string
+				if err := dec(&id); err != nil {
+					return err
+				}
+				return m.Delete(id)
+			case opDeleteExpired:
+				var now time.Time
+				if err := dec(&now); err != nil {
+					return err
+				}
+				return m.DeleteExpired(now)
+			default:
+				return fmt.Errorf("unknown operation %q", op)
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &durableSessionStore{memorySessionStore: m, log: l}, nil
+}
+
+func (st *durableSessionStore) Create(s *Session) error {
+	return st.log.Do(durable.OpCreate, s, nil, func() error { return st.memorySessionStore.Create(s) })
+}
+
+func (st *durableSessionStore) Update(s *Session) error {
+	check := func() error {
+		st.mu.RLock()
+		defer st.mu.RUnlock()
+		return st.updateErr(s)
+	}
+	return st.log.Do(durable.OpUpdate, s, check, func() error { return st.memorySessionStore.Update(s) })
+}
+
+func (st *durableSessionStore) Delete(id string) error {
+	return st.log.Do(durable.OpDelete, id, nil, func() error { return st.memorySessionStore.Delete(id) })
+}
+
+func (st *durableSessionStore) DeleteExpired(now time.Time) error {
+	return st.log.Do(opDeleteExpired, now, nil, func() error { return st.memorySessionStore.DeleteExpired(now) })
+}
+
+func (st *memorySessionStore) all() []Session {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	out := make([]Session, 0, len(st.byID))
+	for _, s := range st.byID {
+		out = append(out, s)
+	}
+	return out
+}
+
+const (
+	opPut         = "put"
+	opDeleteStale = "delete_stale"
+)
+
+type durableAttemptStore struct {
+	*memoryAttemptStore
+	log *durable.Log[Attempts]
+}
+
+func NewDurableAttemptStore(w *wal.Log) (AttemptStore, error) {
+	m := NewMemoryAttemptStore().(*memoryAttemptStore)
+	l, err := durable.Open(w, "login attempts", m.all,
+		func(all []Attempts) error {
+			for _, a := range all {
+				if err := m.Put(a); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(op string, dec wal.Decoder) error {
+			switch op {
+			case opPut:
+				var a Attempts
+				if err := dec(&a); err != nil {
+					return err
+				}
+				return m.Put(a)
+			case durable.OpDelete:
+				var key string
+				if err := dec(&key); err != nil {
+					return err
+				}
+				return m.Delete(key)
+			case opDeleteStale:
+				var before time.Time
+				if err := dec(&before); err != nil {
+					return err
+				}
+				return m.DeleteStale(before)
+			default:
+				return fmt.Errorf("unknown operation %q", op)
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &durableAttemptStore{memoryAttemptStore: m, log: l}, nil
+}
+
+func (st *durableAttemptStore) Put(a Attempts) error {
+	return st.log.Do(opPut, a, nil, func() error { return st.memoryAttemptStore.Put(a) })
+}
+
+func (st *durableAttemptStore) Delete(key string) error {
+	return st.log.Do(durable.OpDelete, key, nil, func() error { return st.memoryAttemptStore.Delete(key) })
+}
+
+func (st *durableAttemptStore) DeleteStale(before time.Time) error {
+	return st.log.Do(opDeleteStale, before, nil, func() error { return st.memoryAttemptStore.DeleteStale(before) })
+}
+
+func (st *memoryAttemptStore) all() []Attempts {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	out := make([]Attempts, 0, len(st.byKey))
+	for _, a := range st.byKey {
+		out = append(out, a)
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attempts counts the failed logins recorded under one key, either an
+// account ("account:<email>") or a client address ("ip:<addr>").
+type Attempts struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// AttemptStore keeps the failed-login counters. Get returns a zero Attempts
+// for keys without failures.
+type AttemptStore interface {
+	Get(key string) (Attempts, error)
+	Put(a Attempts) error
+	Delete(key string) error
+	DeleteStale(before time.Time) error
+}
+
+type LockoutOptions struct {
+	// AccountThreshold and IPThreshold are the failures allowed before a
+	// key gets locked. An address is shared by many accounts, so it gets
+	// more room.
+	AccountThreshold int
+	IPThreshold      int
+	// BaseLockout is the first lockout; it doubles with every further
+	// failure up to MaxLockout.
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+	// ResetAfter forgets the failures of a key that stayed quiet this long.
+	ResetAfter time.Duration
+}
+
+func DefaultLockoutOptions() LockoutOptions {
+	return LockoutOptions{
+		AccountThreshold: 5,
+		IPThreshold:      20,
+		BaseLockout:      30 * time.Second,
+		MaxLockout:       time.Hour,
+		ResetAfter:       24 * time.Hour,
+	}
+}
+
+// LoginGuard applies progressive lockouts to accounts and client addresses
+// that keep failing to log in.
+type LoginGuard struct {
+	mu        sync.Mutex
+	store     AttemptStore
+	opts      LockoutOptions
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewLoginGuard(store AttemptStore, opts LockoutOptions) *LoginGuard {
+	if store == nil {
+		store = NewMemoryAttemptStore()
+	}
+	return &LoginGuard{store: store, opts: opts, now: time.Now}
+}
+
+// Check reports how long the account or the address is still locked, or
+// zero if a login may be attempted.
+func (g *LoginGuard) Check(email, ip string) (time.Duration, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	var wait time.Duration
+	for _, key := range attemptKeys(email, ip) {
+		a, err := g.store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, a.LockedUntil.Sub(now))
+	}
+	return wait, nil
+}
+
+// Fail records a failed login and returns the lockout it caused, if any.
+func (g *LoginGuard) Fail(email, ip string) (time.Duration, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if err := g.sweep(now); err != nil {
+		return 0, err
+	}
+
+	var wait time.Duration
+	for _, key := range attemptKeys(email, ip) {
+		a, err := g.store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if now.Sub(a.LastFailure) > g.opts.ResetAfter {
+			a = Attempts{}
+		}
+		a.Key = key
+		a.Failures++
+		a.LastFailure = now
+
+		if over := a.Failures - g.threshold(key); over >= 0 {
+			lock := g.lockout(over)
+			a.LockedUntil = now.Add(lock)
+			wait = max(wait, lock)
+		}
+		if err := g.store.Put(a); err != nil {
+			return 0, err
+		}
+	}
+	return wait, nil
+}
+
+// Succeed clears the account's failures after a successful login. The
+// address keeps its count, so one valid account cannot be used to reset it.
+func (g *LoginGuard) Succeed(email string) error {
+	return g.Unlock(email)
+}
+
+// Unlock lifts the lockout of an account and forgets its failures.
+func (g *LoginGuard) Unlock(email string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.store.Delete(accountKey(email))
+}
+
+func (g *LoginGuard) threshold(key string) int {
+	if strings.HasPrefix(key, "ip:") {
+		return g.opts.IPThreshold
+	}
+	return g.opts.AccountThreshold
+}
+
+// lockout returns BaseLockout doubled n times, capped at MaxLockout.
+func (g *LoginGuard) lockout(n int) time.Duration {
+	d := g.opts.BaseLockout
+	for range n {
+		if d >= g.opts.MaxLockout {
+			break
+		}
+		d *= 2
+	}
+	return min(d, g.opts.MaxLockout)
+}
+
+// sweep drops forgotten counters at most once per ResetAfter, so addresses
+// that failed once do not pile up.
+func (g *LoginGuard) sweep(now time.Time) error {
+	if now.Sub(g.lastSweep) < g.opts.ResetAfter {
+		return nil
+	}
+	g.lastSweep = now
+	return g.store.DeleteStale(now.Add(-g.opts.ResetAfter))
+}
+
+func attemptKeys(email, ip string) []string {
+	keys := []string{accountKey(email)}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	return keys
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+type memoryAttemptStore struct {
+	mu    sync.RWMutex
+	byKey map[string]Attempts
+}
+
+func NewMemoryAttemptStore() AttemptStore {
+	return &memoryAttemptStore{byKey: make(map[string]Attempts)}
+}
+
+func (st *memoryAttemptStore) Get(key string) (Attempts, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.byKey[key], nil
+}
+
+func (st *memoryAttemptStore) Put(a Attempts) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.byKey[a.Key] = a
+	return nil
+}
+
+func (st *memoryAttemptStore) Delete(key string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.byKey, key)
+	return nil
+}
+
+func (st *memoryAttemptStore) DeleteStale(before time.Time) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for key, a := range st.byKey {
+		if a.LastFailure.Before(before) && a.LockedUntil.Before(before) {
+			delete(st.byKey, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var testLockout = LockoutOptions{
+	AccountThreshold: 3,
+	IPThreshold:      5,
+	BaseLockout:      time.Minute,
+	MaxLockout:       5 * time.Minute,
+	ResetAfter:       time.Hour,
+}
+
+// newTestGuard returns a guard over a memory store whose clock reads *now.
+func newTestGuard(now *time.Time) (*LoginGuard, *memoryAttemptStore) {
+	store := NewMemoryAttemptStore().(*memoryAttemptStore)
+	g := NewLoginGuard(store, testLockout)
+	g.now = func() time.Time { return *now }
+	return g, store
+}
+
+func TestLoginGuardFail(t *testing.T) {
+	tests := []struct {
+		name  string
+		email func(i int) string // of the i-th failure
+		ip    string
+		want  []time.Duration // lockout after each failure
+	}{
+		{
+			name:  "AccountDoublesUpToMax",
+			email: func(int) string { return "ana@example.com" },
+			want:  []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute},
+		},
+		{
+			name:  "AccountKeyIgnoresCaseAndSpaces",
+			email: func(i int) string { return []string{"ana@example.com", " ANA@example.com", "Ana@Example.com "}[i] },
+			want:  []time.Duration{0, 0, time.Minute},
+		},
+		{
+			name:  "AddressHasItsOwnThreshold",
+			email: func(i int) string { return fmt.Sprintf("user%d@example.com", i) },
+			ip:    "10.0.0.1",
+			want:  []time.Duration{0, 0, 0, 0, time.Minute, 2 * time.Minute},
+		},
+		{
+			name:  "LongerLockoutWins",
+			email: func(int) string { return "ana@example.com" },
+			ip:    "10.0.0.1",
+			want:  []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+			g, _ := newTestGuard(&now)
+			for i, want := range tt.want {
+				got, err := g.Fail(tt.email(i), tt.ip)
+				if err != nil {
+					t.Fatalf("Fail: %v", err)
+				}
+				if got != want {
+					t.Fatalf("failure %d: lockout %v, want %v", i+1, got, want)
+				}
+				if wait, _ := g.Check(tt.email(i), tt.ip); wait != want {
+					t.Fatalf("failure %d: Check = %v, want %v", i+1, wait, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoginGuardCheckCountsDown(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	g, _ := newTestGuard(&now)
+	for range 3 {
+		g.Fail("ana@example.com", "")
+	}
+
+	now = now.Add(40 * time.Second)
+	if wait, _ := g.Check("ana@example.com", ""); wait != 20*time.Second {
+		t.Fatalf("Check = %v, want 20s", wait)
+	}
+	now = now.Add(time.Minute)
+	if wait, _ := g.Check("ana@example.com", ""); wait != 0 {
+		t.Fatalf("Check after the lockout = %v, want 0", wait)
+	}
+	// the count stays, so the next failure locks for longer
+	if wait, _ := g.Fail("ana@example.com", ""); wait != 2*time.Minute {
+		t.Fatalf("Fail after the lockout = %v, want 2m", wait)
+	}
+}
+
+func TestLoginGuardResetAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	g, _ := newTestGuard(&now)
+	g.Fail("ana@example.com", "")
+	g.Fail("ana@example.com", "")
+
+	now = now.Add(time.Hour)
+	if wait, _ := g.Fail("ana@example.com", ""); wait != time.Minute {
+		t.Fatalf("Fail within ResetAfter = %v, want 1m", wait)
+	}
+
+	now = now.Add(time.Hour + time.Second)
+	for i, want := range []time.Duration{0, 0, time.Minute} {
+		if wait, _ := g.Fail("ana@example.com", ""); wait != want {
+			t.Fatalf("failure %d after a quiet period: lockout %v, want %v", i+1, wait, want)
+		}
+	}
+}
+
+func TestLoginGuardSucceedKeepsAddressCount(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	g, _ := newTestGuard(&now)
+	for range 2 {
+		g.Fail("ana@example.com", "10.0.0.1")
+	}
+	if err := g.Succeed("ana@example.com"); err != nil {
+		t.Fatalf("Succeed: %v", err)
+	}
+
+	// the account starts over...
+	for i, want := range []time.Duration{0, 0} {
+		if wait, _ := g.Fail("ana@example.com", ""); wait != want {
+			t.Fatalf("account failure %d: lockout %v, want %v", i+1, wait, want)
+		}
+	}
+	// ...but the address already has two failures
+	g.Fail("bia@example.com", "10.0.0.1")
+	g.Fail("caio@example.com", "10.0.0.1")
+	if wait, _ := g.Fail("davi@example.com", "10.0.0.1"); wait != time.Minute {
+		t.Fatalf("fifth failure from the address: lockout %v, want 1m", wait)
+	}
+}
+
+func TestLoginGuardSweep(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	g, store := newTestGuard(&now)
+	g.Fail("ana@example.com", "10.0.0.1")
+
+	// a sweep runs at most once per ResetAfter
+	now = now.Add(50 * time.Minute)
+	g.Fail("bia@example.com", "")
+	now = now.Add(9 * time.Minute)
+	g.Fail("caio@example.com", "")
+	if len(store.byKey) != 4 {
+		t.Fatalf("%d counters before the sweep is due, want 4", len(store.byKey))
+	}
+
+	// an hour after the last sweep, counters quiet for an hour go
+	now = now.Add(2 * time.Minute)
+	g.Fail("davi@example.com", "")
+	for _, key := range []string{"account:ana@example.com", "ip:10.0.0.1"} {
+		if _, ok := store.byKey[key]; ok {
+			t.Fatalf("%s not swept", key)
+		}
+	}
+	if len(store.byKey) != 3 {
+		t.Fatalf("%d counters after the sweep, want 3", len(store.byKey))
+	}
+}
@@ -1,118 +1,500 @@
 package auth
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"sync"
 	"time"
 )
 
+// touchInterval limits how often Get writes the sliding expiry back to the
+// store, so persistent stores are not hit on every request.
+const touchInterval = time.Minute
+
+// minCleanupInterval bounds how often expired sessions are deleted, however
+// short the idle timeout.
+const minCleanupInterval = time.Minute
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired token")
+	ErrTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// SessionOptions configures a SessionManager. Unset options take their
+// DefaultSessionOptions value.
+type SessionOptions struct {
+	IdleTimeout         time.Duration
+	MaxLifetime         time.Duration
+	RememberIdleTimeout time.Duration
+	RememberMaxLifetime time.Duration
+
+	// AccessTokenTTL is the fixed lifetime of bearer access tokens.
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is how long a refresh token stays usable; a token
+	// family never outlives RememberMaxLifetime.
+	RefreshTokenTTL time.Duration
+
+	// ImpersonationTTL is the absolute lifetime of impersonation sessions.
+	ImpersonationTTL time.Duration
+}
+
+func DefaultSessionOptions() SessionOptions {
+	return SessionOptions{
+		IdleTimeout:         30 * time.Minute,
+		MaxLifetime:         12 * time.Hour,
+		RememberIdleTimeout: 14 * 24 * time.Hour,
+		RememberMaxLifetime: 90 * 24 * time.Hour,
+		AccessTokenTTL:      15 * time.Minute,
+		RefreshTokenTTL:     30 * 24 * time.Hour,
+		ImpersonationTTL:    time.Hour,
+	}
+}
+
+// ClientInfo describes the device a session was started from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionInfo is the user-facing view of a login. All tokens of a bearer
+// login are reported as one entry identified by their family.
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
 type SessionManager struct {
 	mu     sync.RWMutex
-	ttl    time.Duration
-	byID   map[string]session
+	store  SessionStore
+	opts   SessionOptions
 	secure bool
+	now    func() time.Time
 	done   chan struct{}
-}
 
-type session struct {
-	UserID string
-	ExpAt  time.Time
+	csrfKey []byte
 }
 
-func NewSessionManager(ttl time.Duration) *SessionManager {
+func NewSessionManager(store SessionStore, opts SessionOptions) *SessionManager {
+	if store == nil {
+		store = NewMemorySessionStore()
+	}
 	m := &SessionManager{
-		ttl:    ttl,
-		byID:   make(map[string]session),
-		secure: false,
-		done:   make(chan struct{}),
+		store:   store,
+		opts:    opts.withDefaults(),
+		secure:  false,
+		now:     time.Now,
+		done:    make(chan struct{}),
+		csrfKey: make([]byte, 32),
 	}
-	go m.cleanupLoop()
+	rand.Read(m.csrfKey)
+	go m.cleanupLoop(time.NewTicker(max(m.opts.IdleTimeout/2, minCleanupInterval)))
 	return m
 }
 
+func (o SessionOptions) withDefaults() SessionOptions {
+	def := DefaultSessionOptions()
+	for _, d := range []struct{ opt, def *time.Duration }{
+		{&o.IdleTimeout, &def.IdleTimeout},
+		{&o.MaxLifetime, &def.MaxLifetime},
+		{&o.RememberIdleTimeout, &def.RememberIdleTimeout},
+		{&o.RememberMaxLifetime, &def.RememberMaxLifetime},
+		{&o.AccessTokenTTL, &def.AccessTokenTTL},
+		{&o.RefreshTokenTTL, &def.RefreshTokenTTL},
+		{&o.ImpersonationTTL, &def.ImpersonationTTL},
+	} {
+		if *d.opt <= 0 {
+			*d.opt = *d.def
+		}
+	}
+	return o
+}
+
 func (m *SessionManager) SetSecure(secure bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.secure = secure
 }
 
+// SetCSRFKey sets the secret CSRF tokens are derived from. Without it a
+// random key is used, and tokens stop matching after a restart.
+func (m *SessionManager) SetCSRFKey(key []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.csrfKey = key
+}
+
+// CSRFToken returns the CSRF token bound to the session sid. It is derived
+// from the session, so it needs no storage and dies with the session.
+func (m *SessionManager) CSRFToken(sid string) string {
+	m.mu.RLock()
+	key := m.csrfKey
+	m.mu.RUnlock()
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(hashToken(sid)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidCSRFToken reports whether token belongs to the session sid.
+func (m *SessionManager) ValidCSRFToken(sid, token string) bool {
+	return hmac.Equal([]byte(m.CSRFToken(sid)), []byte(token))
+}
+
 func (m *SessionManager) Close() {
 	close(m.done)
 }
 
-func (m *SessionManager) cleanupLoop() {
-	ticker := time.NewTicker(m.ttl / 2)
+func (m *SessionManager) cleanupLoop(ticker *time.Ticker) {
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			m.cleanup()
+			if err := m.store.DeleteExpired(m.now()); err != nil {
+				log.Printf("failed to delete expired sessions: %v", err)
+			}
 		case <-m.done:
 			return
 		}
 	}
 }
 
-func (m *SessionManager) cleanup() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// New starts a session for userID and returns the token to hand to the
+// client. Remember-me sessions use the longer remember timeouts.
+func (m *SessionManager) New(userID string, remember bool, client ClientInfo) (string, error) {
+	sid, err := newToken()
+	if err != nil {
+		return "", err
+	}
 
-	now := time.Now()
-	for sid, s := range m.byID {
-		if now.After(s.ExpAt) {
-			delete(m.byID, sid)
-		}
+	idle, max := m.timeouts(remember)
+	now := m.now()
+	s := &Session{
+		ID:         hashToken(sid),
+		UserID:     userID,
+		Remember:   remember,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpAt:      now.Add(idle),
+		MaxExpAt:   now.Add(max),
 	}
+	if err := m.store.Create(s); err != nil {
+		return "", err
+	}
+	return sid, nil
 }
 
-func (m *SessionManager) New(userID string) (string, error) {
-	var b [32]byte
-	if _, err := rand.Read(b[:]); err != nil {
-		return "", fmt.Errorf("failed to generate session ID: %w", err)
+// NewImpersonation starts a session in which adminID acts as userID. It
+// behaves like a regular session but cannot be remembered and ends after
+// ImpersonationTTL.
+func (m *SessionManager) NewImpersonation(adminID, userID string, client ClientInfo) (string, error) {
+	sid, err := newToken()
+	if err != nil {
+		return "", err
 	}
-	sid := hex.EncodeToString(b[:])
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.byID[sid] = session{UserID: userID, ExpAt: time.Now().Add(m.ttl)}
+	idle, _ := m.timeouts(false)
+	now := m.now()
+	maxExp := now.Add(m.opts.ImpersonationTTL)
+	s := &Session{
+		ID:             hashToken(sid),
+		UserID:         userID,
+		UserAgent:      client.UserAgent,
+		IP:             client.IP,
+		CreatedAt:      now,
+		LastSeenAt:     now,
+		ExpAt:          minTime(now.Add(idle), maxExp),
+		MaxExpAt:       maxExp,
+		ImpersonatorID: adminID,
+	}
+	if err := m.store.Create(s); err != nil {
+		return "", err
+	}
 	return sid, nil
 }
 
+// Get resolves a session token to its user and slides the idle expiry
+// forward, never past the absolute lifetime.
 func (m *SessionManager) Get(sid string) (string, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	s, ok := m.byID[sid]
-	if !ok || time.Now().After(s.ExpAt) {
+	s, ok := m.Lookup(sid)
+	if !ok {
 		return "", false
 	}
 	return s.UserID, true
 }
 
+// Lookup is Get returning the whole session.
+func (m *SessionManager) Lookup(sid string) (*Session, bool) {
+	s, err := m.store.ByID(hashToken(sid))
+	if err != nil || s.Kind == KindRefresh {
+		return nil, false
+	}
+	now := m.now()
+	if s.expired(now) {
+		if err := m.store.Delete(s.ID); err != nil {
+			log.Printf("failed to delete expired session: %v", err)
+		}
+		return nil, false
+	}
+
+	if now.Sub(s.LastSeenAt) >= touchInterval {
+		idle, _ := m.timeouts(s.Remember)
+		s.LastSeenAt = now
+		s.ExpAt = now.Add(idle)
+		if s.ExpAt.After(s.MaxExpAt) {
+			s.ExpAt = s.MaxExpAt
+		}
+		if err := m.store.Update(s); err != nil {
+			log.Printf("failed to renew session: %v", err)
+		}
+	}
+	return s, true
+}
+
+// Active reports whether sid is a live cookie session. Unlike Lookup it
+// does not renew the session.
+func (m *SessionManager) Active(sid string) bool {
+	s, err := m.store.ByID(hashToken(sid))
+	return err == nil && s.Kind != KindRefresh && !s.expired(m.now())
+}
+
+// Delete ends the session behind sid. For bearer tokens the whole family is
+// revoked, so logging out with an access token also kills its refresh token.
 func (m *SessionManager) Delete(sid string) {
+	id := hashToken(sid)
+	if s, err := m.store.ByID(id); err == nil && s.FamilyID != "" {
+		m.revokeFamily(s.UserID, s.FamilyID)
+		return
+	}
+	if err := m.store.Delete(id); err != nil {
+		log.Printf("failed to delete session: %v", err)
+	}
+}
+
+// NewTokenPair starts a bearer-token login for clients that cannot rely on
+// cookies.
+func (m *SessionManager) NewTokenPair(userID string, client ClientInfo) (*TokenPair, error) {
+	familyID, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	return m.issuePair(userID, familyID, m.now().Add(m.opts.RememberMaxLifetime), client)
+}
+
+// Refresh exchanges a refresh token for a new pair. Each refresh token works
+// once; presenting an already rotated one means it leaked, so the whole
+// family is revoked and ErrTokenReused is returned.
+func (m *SessionManager) Refresh(refreshToken string, client ClientInfo) (*TokenPair, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	delete(m.byID, sid)
+
+	s, err := m.store.ByID(hashToken(refreshToken))
+	if err != nil || s.Kind != KindRefresh {
+		return nil, ErrInvalidToken
+	}
+	if s.Rotated {
+		m.revokeFamily(s.UserID, s.FamilyID)
+		return nil, ErrTokenReused
+	}
+	if s.expired(m.now()) {
+		return nil, ErrInvalidToken
+	}
+
+	// the rotated token is kept until the family expires, so a replay
+	// after its own lifetime still revokes the family
+	s.Rotated = true
+	s.LastSeenAt = m.now()
+	s.ExpAt = s.MaxExpAt
+	if err := m.store.Update(s); err != nil {
+		return nil, err
+	}
+	return m.issuePair(s.UserID, s.FamilyID, s.MaxExpAt, client)
+}
+
+func (m *SessionManager) issuePair(userID, familyID string, familyExp time.Time, client ClientInfo) (*TokenPair, error) {
+	access, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	accessExp := minTime(now.Add(m.opts.AccessTokenTTL), familyExp)
+	err = m.store.Create(&Session{
+		ID: hashToken(access), UserID: userID, Kind: KindAccess, FamilyID: familyID,
+		UserAgent: client.UserAgent, IP: client.IP, CreatedAt: now, LastSeenAt: now, ExpAt: accessExp, MaxExpAt: accessExp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = m.store.Create(&Session{
+		ID: hashToken(refresh), UserID: userID, Kind: KindRefresh, FamilyID: familyID,
+		UserAgent: client.UserAgent, IP: client.IP, CreatedAt: now, LastSeenAt: now, ExpAt: minTime(now.Add(m.opts.RefreshTokenTTL), familyExp), MaxExpAt: familyExp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{AccessToken: access, RefreshToken: refresh, ExpiresIn: accessExp.Sub(now)}, nil
+}
+
+// List returns the live logins of userID; currentToken marks the one the
+// request was made with.
+func (m *SessionManager) List(userID, currentToken string) ([]SessionInfo, error) {
+	list, err := m.store.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	current := hashToken(currentToken)
+	out := make([]SessionInfo, 0, len(list))
+	families := make(map[string]int)
+	started := make(map[string]time.Time) // first token of each family
+	for _, s := range list {
+		if s.FamilyID != "" {
+			if t, ok := started[s.FamilyID]; !ok || s.CreatedAt.Before(t) {
+				started[s.FamilyID] = s.CreatedAt
+			}
+		}
+		// rotated refresh tokens are only kept to recognise replays
+		if s.expired(now) || s.Rotated {
+			continue
+		}
+		if s.FamilyID == "" {
+			typ := "cookie"
+			if s.ImpersonatorID != "" {
+				typ = "impersonation"
+			}
+			out = append(out, SessionInfo{
+				ID: s.ID, Type: typ, UserAgent: s.UserAgent, IP: s.IP,
+				CreatedAt: s.CreatedAt, LastSeenAt: s.LastSeenAt, ExpiresAt: s.ExpAt, Current: s.ID == current,
+			})
+			continue
+		}
+
+		i, seen := families[s.FamilyID]
+		if !seen {
+			i = len(out)
+			families[s.FamilyID] = i
+			out = append(out, SessionInfo{ID: s.FamilyID, Type: "token"})
+		}
+		info := &out[i]
+		if !s.LastSeenAt.Before(info.LastSeenAt) {
+			info.LastSeenAt, info.UserAgent, info.IP = s.LastSeenAt, s.UserAgent, s.IP
+		}
+		if s.Kind == KindRefresh {
+			info.ExpiresAt = s.ExpAt
+		}
+		if s.ID == current {
+			info.Current = true
+		}
+	}
+	for i := range out {
+		if out[i].Type == "token" {
+			out[i].CreatedAt = started[out[i].ID]
+		}
+	}
+	return out, nil
+}
+
+// Revoke ends one of userID's logins, identified as in List.
+func (m *SessionManager) Revoke(userID, id string) error {
+	list, err := m.store.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		if s.FamilyID != "" && s.FamilyID == id {
+			m.revokeFamily(userID, id)
+			return nil
+		}
+		if s.FamilyID == "" && s.ID == id {
+			return m.store.Delete(s.ID)
+		}
+	}
+	return ErrSessionNotFound
+}
+
+// RevokeAll logs userID out everywhere.
+func (m *SessionManager) RevokeAll(userID string) error {
+	list, err := m.store.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		if err := m.store.Delete(s.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+func (m *SessionManager) revokeFamily(userID, familyID string) {
+	list, err := m.store.ListByUser(userID)
+	if err != nil {
+		log.Printf("failed to list sessions for user %s: %v", userID, err)
+		return
+	}
+	for _, s := range list {
+		if s.FamilyID != familyID {
+			continue
+		}
+		if err := m.store.Delete(s.ID); err != nil {
+			log.Printf("failed to revoke session: %v", err)
+		}
+	}
+}
+
+func (m *SessionManager) timeouts(remember bool) (idle, max time.Duration) {
+	if remember {
+		return m.opts.RememberIdleTimeout, m.opts.RememberMaxLifetime
+	}
+	return m.opts.IdleTimeout, m.opts.MaxLifetime
+}
+
+// SetCookie writes the session cookie. Remember-me sessions get a persistent
+// cookie that lasts until their absolute expiry; regular sessions use a
+// browser-session cookie.
 func (m *SessionManager) SetCookie(w http.ResponseWriter, sid string) {
 	m.mu.RLock()
 	secure := m.secure
 	m.mu.RUnlock()
 
-	http.SetCookie(w, &http.Cookie{
+	c := &http.Cookie{
 		Name:     "sid",
 		Value:    sid,
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   secure,
 		SameSite: http.SameSiteLaxMode,
-		MaxAge:   int(m.ttl.Seconds()),
-	})
+	}
+	if s, err := m.store.ByID(hashToken(sid)); err == nil && s.Remember {
+		c.MaxAge = int(s.MaxExpAt.Sub(m.now()).Seconds())
+	}
+	http.SetCookie(w, c)
 }
 
 func (m *SessionManager) ClearCookie(w http.ResponseWriter) {
@@ -129,3 +511,23 @@ func (m *SessionManager) ClearCookie(w http.ResponseWriter) {
 		MaxAge:   -1,
 	})
 }
+
+func newToken() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
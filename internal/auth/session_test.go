@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestManager returns a manager over a memory store whose clock reads
+// *now.
+func newTestManager(t *testing.T, now *time.Time) (*SessionManager, SessionStore) {
+	t.Helper()
+	store := NewMemorySessionStore()
+	m := NewSessionManager(store, DefaultSessionOptions())
+	t.Cleanup(m.Close)
+	m.now = func() time.Time { return *now }
+	return m, store
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(t, &now)
+
+	first, err := m.NewTokenPair("u1", ClientInfo{})
+	if err != nil {
+		t.Fatalf("NewTokenPair: %v", err)
+	}
+	now = now.Add(time.Hour)
+	second, err := m.Refresh(first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatal("Refresh returned the same tokens")
+	}
+	if _, ok := m.Lookup(second.AccessToken); !ok {
+		t.Fatal("new access token rejected")
+	}
+	if _, err := m.Refresh(second.RefreshToken, ClientInfo{}); err != nil {
+		t.Fatalf("Refresh with the new token: %v", err)
+	}
+
+	list, err := m.List("u1", "")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 1 || !list[0].CreatedAt.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("List = %+v, want one login created at the first pair", list)
+	}
+}
+
+func TestRefreshDetectsReuse(t *testing.T) {
+	tests := []struct {
+		name  string
+		after time.Duration // between the rotation and the replay
+		want  error
+	}{
+		{"Soon", time.Minute, ErrTokenReused},
+		// past RefreshTokenTTL, but within the family's lifetime
+		{"AfterTokenLifetime", 40 * 24 * time.Hour, ErrTokenReused},
+		{"AfterFamilyLifetime", 91 * 24 * time.Hour, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+			m, store := newTestManager(t, &now)
+
+			stolen, err := m.NewTokenPair("u1", ClientInfo{})
+			if err != nil {
+				t.Fatalf("NewTokenPair: %v", err)
+			}
+			now = now.Add(time.Hour)
+			if _, err := m.Refresh(stolen.RefreshToken, ClientInfo{}); err != nil {
+				t.Fatalf("Refresh: %v", err)
+			}
+			// an attacker-held login of the same family, kept alive
+			now = now.Add(tt.after)
+			live, err := m.issuePair("u1", familyOf(t, store, stolen.RefreshToken), now.Add(time.Hour), ClientInfo{})
+			if err != nil {
+				t.Fatalf("issuePair: %v", err)
+			}
+			if err := store.DeleteExpired(now); err != nil {
+				t.Fatalf("DeleteExpired: %v", err)
+			}
+
+			if _, err := m.Refresh(stolen.RefreshToken, ClientInfo{}); !errors.Is(err, tt.want) {
+				t.Fatalf("replay: Refresh = %v, want %v", err, tt.want)
+			}
+			if tt.want != ErrTokenReused {
+				return
+			}
+			if _, ok := m.Lookup(live.AccessToken); ok {
+				t.Fatal("access token of the family still valid after the replay")
+			}
+			if _, err := m.Refresh(live.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("refresh token of the family: Refresh = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestRefreshRejectsExpiredToken(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(t, &now)
+
+	pair, err := m.NewTokenPair("u1", ClientInfo{})
+	if err != nil {
+		t.Fatalf("NewTokenPair: %v", err)
+	}
+	now = now.Add(DefaultSessionOptions().RefreshTokenTTL + time.Second)
+	if _, err := m.Refresh(pair.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Refresh = %v, want ErrInvalidToken", err)
+	}
+}
+
+func familyOf(t *testing.T, store SessionStore, token string) string {
+	t.Helper()
+	s, err := store.ByID(hashToken(token))
+	if err != nil {
+		t.Fatalf("ByID: %v", err)
+	}
+	return s.FamilyID
+}
+
+func TestNewSessionManagerDefaultsOptions(t *testing.T) {
+	for _, opts := range []SessionOptions{{}, {IdleTimeout: 1, MaxLifetime: time.Hour}} {
+		m := NewSessionManager(nil, opts)
+		m.Close()
+		if m.opts.RefreshTokenTTL != DefaultSessionOptions().RefreshTokenTTL {
+			t.Fatalf("%+v: RefreshTokenTTL = %v, want the default", opts, m.opts.RefreshTokenTTL)
+		}
+	}
+	m := NewSessionManager(nil, SessionOptions{IdleTimeout: time.Minute})
+	m.Close()
+	if m.opts.IdleTimeout != time.Minute || m.opts.MaxLifetime != DefaultSessionOptions().MaxLifetime {
+		t.Fatalf("opts = %+v, want the given idle timeout and the default lifetime", m.opts)
+	}
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type TokenKind string
+
+const (
+	KindCookie  TokenKind = ""
+	KindAccess  TokenKind = "access"
+	KindRefresh TokenKind = "refresh"
+)
+
+// Session is keyed by the SHA-256 of the token handed to the client, so a
+// leaked store cannot be replayed as cookies. Access and refresh tokens are
+// sessions too; the ones issued by one login share a FamilyID.
+type Session struct {
+	ID         string
+	UserID     string
+	Kind       TokenKind
+	FamilyID   string
+	Rotated    bool // refresh token already exchanged for a new pair
+	Remember   bool
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpAt      time.Time // idle expiry, pushed forward on use
+	MaxExpAt   time.Time // absolute expiry, never moved
+
+	// ImpersonatorID is the admin acting as UserID, for sessions started
+	// with NewImpersonation.
+	ImpersonatorID string
+}
+
+func (s Session) expired(now time.Time) bool {
+	return now.After(s.ExpAt) || now.After(s.MaxExpAt)
+}
+
+type SessionStore interface {
+	Create(s *Session) error
+	ByID(id string) (*Session, error)
+	Update(s *Session) error
+	Delete(id string) error
+	DeleteExpired(now time.Time) error
+	ListByUser(userID string) ([]Session, error)
+}
+
+type memorySessionStore struct {
+	mu   sync.RWMutex
+	byID map[string]Session
+}
+
+func NewMemorySessionStore() SessionStore {
+	return &memorySessionStore{byID: make(map[string]Session)}
+}
+
+func (st *memorySessionStore) Create(s *Session) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.byID[s.ID] = *s
+	return nil
+}
+
+func (st *memorySessionStore) ByID(id string) (*Session, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	s, ok := st.byID[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &s, nil
+}
+
+func (st *memorySessionStore) Update(s *Session) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if err := st.updateErr(s); err != nil {
+		return err
+	}
+	st.byID[s.ID] = *s
+	return nil
+}
+
+// updateErr returns the error Update fails with for s; st.mu must be held.
+func (st *memorySessionStore) updateErr(s *Session) error {
+	if _, ok := st.byID[s.ID]; !ok {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (st *memorySessionStore) Delete(id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.byID, id)
+	return nil
+}
+
+func (st *memorySessionStore) DeleteExpired(now time.Time) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for id, s := range st.byID {
+		if s.expired(now) {
+			delete(st.byID, id)
+		}
+	}
+	return nil
+}
+
+func (st *memorySessionStore) ListByUser(userID string) ([]Session, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+
+	out := make([]Session, 0)
+	for _, s := range st.byID {
+		if s.UserID == userID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
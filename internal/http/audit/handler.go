@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+
+	domain "github.com/Gab-Mello/service-finder/internal/audit"
+	"github.com/Gab-Mello/service-finder/internal/http/response"
+)
+
+type Handler struct{ log *domain.Log }
+
+func NewHandler(l *domain.Log) *Handler { return &Handler{log: l} }
+
+// List returns audit entries, newest first, filtered by actor and target.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := domain.Query{
+		ActorID:  q.Get("actor"),
+		TargetID: q.Get("target"),
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			response.Error(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		query.Limit = n
+	}
+
+	entries, err := h.log.List(query)
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{"items": entries})
+}
@@ -0,0 +1,16 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+func Register(mux *http.ServeMux, h *Handler, sessions *auth.SessionManager, users authmw.UserLoader) {
+	const api = "/api/v1"
+	admin := authmw.WithRole(sessions, users, user.RoleAdmin)
+
+	mux.HandleFunc("GET "+api+"/admin/audit", admin(h.List))
+}
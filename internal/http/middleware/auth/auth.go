@@ -3,37 +3,200 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/Gab-Mello/service-finder/internal/auth"
+	"github.com/Gab-Mello/service-finder/internal/user"
 )
 
 type ctxKey string
 
-const userIDKey ctxKey = "userID"
+const (
+	userIDKey       ctxKey = "userID"
+	principalKey    ctxKey = "principal"
+	impersonatorKey ctxKey = "impersonator"
+	apiKeyKey       ctxKey = "apiKey"
+	scopeKey        ctxKey = "scope"
+	loginAtKey      ctxKey = "loginAt"
+)
+
+// APIKeyHeader carries the API key of integrations.
+const APIKeyHeader = "X-API-Key"
+
+// UserLoader resolves the user behind an authenticated session or API key.
+type UserLoader interface {
+	ByID(id string) (*user.User, error)
+	AuthenticateAPIKey(key string) (*user.User, *user.APIKey, error)
+}
 
 func UserIDFromContext(r *http.Request) (string, bool) {
 	uid, ok := r.Context().Value(userIDKey).(string)
 	return uid, ok
 }
 
-func WithAuth(sessions *auth.SessionManager, next http.HandlerFunc) http.HandlerFunc {
+// PrincipalFromContext returns the user loaded by WithAuth.
+func PrincipalFromContext(r *http.Request) (*user.User, bool) {
+	u, ok := r.Context().Value(principalKey).(*user.User)
+	return u, ok
+}
+
+// ImpersonatorFromContext returns the admin acting as the principal when the
+// request was made with an impersonation session.
+func ImpersonatorFromContext(r *http.Request) (string, bool) {
+	id, ok := r.Context().Value(impersonatorKey).(string)
+	return id, ok
+}
+
+// APIKeyFromContext returns the API key the request was authenticated with.
+func APIKeyFromContext(r *http.Request) (*user.APIKey, bool) {
+	k, ok := r.Context().Value(apiKeyKey).(*user.APIKey)
+	return k, ok
+}
+
+// LoginTimeFromContext returns when the user logged in with the session
+// cookie of the request. Tokens, API keys and impersonation sessions do not
+// have one.
+func LoginTimeFromContext(r *http.Request) (time.Time, bool) {
+	t, ok := r.Context().Value(loginAtKey).(time.Time)
+	return t, ok
+}
+
+// WithScope opens a route to API keys granted scope. It wraps the
+// authenticating middleware; routes without it refuse API keys.
+func WithScope(scope user.Scope, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		c, err := r.Cookie("sid")
-		if err != nil {
+		next(w, r.WithContext(context.WithValue(r.Context(), scopeKey, scope)))
+	}
+}
+
+// WithAuth accepts an API key in X-API-Key, an "Authorization: Bearer"
+// access token or the sid cookie, in that order; a request is never
+// authenticated by a later one when an earlier one is present. The user is
+// loaded and stored as the request principal; suspended users get 403 and
+// lose all their sessions. API keys pass only on routes wrapped with
+// WithScope for a scope they hold.
+func WithAuth(sessions *auth.SessionManager, users UserLoader, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			withAPIKey(users, key, next)(w, r)
+			return
+		}
+		token, ok := Token(r)
+		if !ok {
 			writeJSONErr(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
-		uid, ok := sessions.Get(c.Value)
+		session, ok := sessions.Lookup(token)
 		if !ok {
 			writeJSONErr(w, http.StatusUnauthorized, "unauthorized")
 			return
 		}
+		uid := session.UserID
+		u, err := users.ByID(uid)
+		if err != nil {
+			writeJSONErr(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if u.Suspended(time.Now()) {
+			if err := sessions.RevokeAll(uid); err != nil {
+				log.Printf("failed to revoke sessions of suspended user %s: %v", uid, err)
+			}
+			writeJSONErr(w, http.StatusForbidden, user.ErrSuspended.Error())
+			return
+		}
 		ctx := context.WithValue(r.Context(), userIDKey, uid)
+		ctx = context.WithValue(ctx, principalKey, u)
+		if session.ImpersonatorID != "" {
+			ctx = context.WithValue(ctx, impersonatorKey, session.ImpersonatorID)
+		} else if session.Kind == auth.KindCookie {
+			ctx = context.WithValue(ctx, loginAtKey, session.CreatedAt)
+		}
 		next(w, r.WithContext(ctx))
 	}
 }
 
+func withAPIKey(users UserLoader, key string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, k, err := users.AuthenticateAPIKey(key)
+		switch {
+		case errors.Is(err, user.ErrSuspended):
+			writeJSONErr(w, http.StatusForbidden, err.Error())
+			return
+		case err != nil:
+			writeJSONErr(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		scope, _ := r.Context().Value(scopeKey).(user.Scope)
+		if scope == "" || !k.HasScope(scope) {
+			writeJSONErr(w, http.StatusForbidden, "insufficient scope")
+			return
+		}
+		ctx := context.WithValue(r.Context(), userIDKey, u.ID)
+		ctx = context.WithValue(ctx, principalKey, u)
+		ctx = context.WithValue(ctx, apiKeyKey, k)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// NotImpersonating guards actions an admin must not take on a user's behalf,
+// such as changing credentials. It goes inside WithAuth.
+func NotImpersonating(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := ImpersonatorFromContext(r); ok {
+			writeJSONErr(w, http.StatusForbidden, "not allowed while impersonating")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// WithRole authenticates like WithAuth. Users holding none of roles get
+// 403; with no roles any authenticated user passes.
+func WithRole(sessions *auth.SessionManager, users UserLoader, roles ...user.Role) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return WithAuth(sessions, users, func(w http.ResponseWriter, r *http.Request) {
+			u, _ := PrincipalFromContext(r)
+			if len(roles) > 0 && !slices.ContainsFunc(roles, u.HasRole) {
+				writeJSONErr(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			next(w, r)
+		})
+	}
+}
+
+// Token returns the session token presented by the request, preferring the
+// bearer token over the cookie.
+func Token(r *http.Request) (string, bool) {
+	if h := r.Header.Get("Authorization"); h != "" {
+		scheme, token, found := strings.Cut(h, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return "", false
+		}
+		return strings.TrimSpace(token), true
+	}
+	c, err := r.Cookie("sid")
+	if err != nil {
+		return "", false
+	}
+	return c.Value, true
+}
+
+// ClientInfo describes the device making the request, for session listings.
+func ClientInfo(r *http.Request) auth.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return auth.ClientInfo{UserAgent: r.UserAgent(), IP: ip}
+}
+
 func writeJSONErr(w http.ResponseWriter, status int, msg string) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(status)
@@ -16,6 +16,7 @@ func DefaultCORSConfig() CORSConfig {
 		AllowedHeaders: []string{
 			"Content-Type",
 			"Authorization",
+			CSRFHeader,
 			"X-Requested-With",
 			"Accept",
 			"Origin",
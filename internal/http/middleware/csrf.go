@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
+)
+
+const CSRFHeader = "X-CSRF-Token"
+
+// CSRF rejects state-changing requests authenticated by the sid cookie of
+// a live session unless they echo the session's CSRF token in the X-CSRF-Token header.
+// Requests with an Authorization or X-API-Key header are exempt: browsers
+// never attach them on their own, and WithAuth ignores the cookie when one
+// is present.
+func CSRF(sessions *auth.SessionManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.Header.Get("Authorization") != "" || r.Header.Get(authmw.APIKeyHeader) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// a cookie without a live session authenticates nothing; it must
+			// not keep its owner from logging in again
+			c, err := r.Cookie("sid")
+			if err != nil || c.Value == "" || !sessions.Active(c.Value) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !sessions.ValidCSRFToken(c.Value, r.Header.Get(CSRFHeader)) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid csrf token"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
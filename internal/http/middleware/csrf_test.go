@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
+)
+
+func TestCSRF(t *testing.T) {
+	sessions := auth.NewSessionManager(nil, auth.SessionOptions{
+		IdleTimeout: time.Hour, MaxLifetime: time.Hour,
+	})
+	t.Cleanup(sessions.Close)
+
+	victim, err := sessions.New("victim", false, auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	attacker, err := sessions.New("attacker", false, auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	revoked, err := sessions.New("victim", false, auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	sessions.Delete(revoked)
+
+	h := CSRF(sessions)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		cookie  string
+		headers map[string]string
+		want    int
+	}{
+		{name: "SafeMethod", method: http.MethodGet, path: "/api/v1/me", cookie: victim, want: http.StatusNoContent},
+		{name: "NoCookie", method: http.MethodPost, path: "/api/v1/login", want: http.StatusNoContent},
+		{name: "MissingToken", method: http.MethodPost, path: "/api/v1/postings", cookie: victim, want: http.StatusForbidden},
+		{
+			name: "WrongToken", method: http.MethodDelete, path: "/api/v1/me", cookie: victim,
+			headers: map[string]string{CSRFHeader: "not-a-token"}, want: http.StatusForbidden,
+		},
+		{
+			// a cross-origin page that got hold of its own session's token
+			name: "TokenOfAnotherSession", method: http.MethodPost, path: "/api/v1/orders", cookie: victim,
+			headers: map[string]string{CSRFHeader: sessions.CSRFToken(attacker)}, want: http.StatusForbidden,
+		},
+		{
+			name: "ValidToken", method: http.MethodPatch, path: "/api/v1/me", cookie: victim,
+			headers: map[string]string{CSRFHeader: sessions.CSRFToken(victim)}, want: http.StatusNoContent,
+		},
+		{name: "RevokedCookieOnLogin", method: http.MethodPost, path: "/api/v1/login", cookie: revoked, want: http.StatusNoContent},
+		{name: "UnknownCookieOnLogin", method: http.MethodPost, path: "/api/v1/login", cookie: "forgotten", want: http.StatusNoContent},
+		{
+			name: "BearerExempt", method: http.MethodPost, path: "/api/v1/postings", cookie: victim,
+			headers: map[string]string{"Authorization": "Bearer token"}, want: http.StatusNoContent,
+		},
+		{
+			name: "APIKeyExempt", method: http.MethodPost, path: "/api/v1/postings", cookie: victim,
+			headers: map[string]string{authmw.APIKeyHeader: "sf_key"}, want: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "sid", Value: tt.cookie})
+			}
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
+)
+
+// AuditImpersonatedRequest is the audit action of requests made with an
+// impersonation session.
+const AuditImpersonatedRequest = "impersonation.request"
+
+// Auditor records actions in the audit trail.
+type Auditor interface {
+	Record(actorID, action, targetID string, details map[string]string) error
+}
+
+// AuditImpersonation records every request made with an impersonation
+// session, whatever its outcome, with the admin as actor and the
+// impersonated user as target.
+func AuditImpersonation(sessions *auth.SessionManager, audit Auditor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := authmw.Token(r)
+			if !ok || r.Header.Get(authmw.APIKeyHeader) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			s, ok := sessions.Lookup(token)
+			if !ok || s.ImpersonatorID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			err := audit.Record(s.ImpersonatorID, AuditImpersonatedRequest, s.UserID, map[string]string{
+				"method": r.Method,
+				"path":   r.URL.Path,
+				"status": strconv.Itoa(rec.status),
+			})
+			if err != nil {
+				log.Printf("failed to audit impersonated request of %s as %s: %v", s.ImpersonatorID, s.UserID, err)
+			}
+		})
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
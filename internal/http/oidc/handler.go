@@ -0,0 +1,127 @@
+package oidc
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
+	"github.com/Gab-Mello/service-finder/internal/http/response"
+	"github.com/Gab-Mello/service-finder/internal/oidc"
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+const (
+	basePath    = "/api/v1/auth/oidc/"
+	stateCookie = "oidc_state"
+)
+
+type Handler struct {
+	client   *oidc.Client
+	users    *user.Service
+	sessions *auth.SessionManager
+	appURL   string
+}
+
+// NewHandler serves the browser side of OIDC logins; after a successful
+// callback the browser is sent back to appURL with a session cookie, or to
+// appURL/login/2fa with a login challenge in the fragment when the account
+// has two-factor enabled.
+func NewHandler(client *oidc.Client, users *user.Service, sessions *auth.SessionManager, appURL string) *Handler {
+	return &Handler{client: client, users: users, sessions: sessions, appURL: strings.TrimRight(appURL, "/")}
+}
+
+func (h *Handler) Providers(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, map[string]any{"providers": h.client.Providers()})
+}
+
+// Start redirects to the identity provider. The optional role query
+// parameter is used if the login creates a new account.
+func (h *Handler) Start(w http.ResponseWriter, r *http.Request) {
+	provider := response.PathParam(r.URL.Path, basePath, "/start")
+	f, url, err := h.client.Start(provider, r.URL.Query().Get("role"))
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	// binds the callback to this browser, so a victim cannot be made to
+	// complete a login the attacker started
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    f.State,
+		Path:     basePath,
+		MaxAge:   int(time.Until(f.ExpiresAt).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+func (h *Handler) Callback(w http.ResponseWriter, r *http.Request) {
+	provider := response.PathParam(r.URL.Path, basePath, "/callback")
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		response.Error(w, http.StatusUnauthorized, "identity provider denied the login: "+e)
+		return
+	}
+
+	c, err := r.Cookie(stateCookie)
+	if err != nil || c.Value == "" || c.Value != q.Get("state") {
+		mapErr(w, oidc.ErrInvalidState)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: basePath, MaxAge: -1, HttpOnly: true})
+
+	f, profile, err := h.client.Finish(r.Context(), provider, q.Get("state"), q.Get("code"))
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	u, err := h.users.LoginExternal(profile, f.Role)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+
+	// the identity provider stands in for the password only; the second
+	// factor is still asked for, by the app through POST /login/2fa
+	if u.TwoFactor.Enabled {
+		challenge, err := h.users.NewLoginChallenge(u.ID)
+		if err != nil {
+			response.InternalError(w, err)
+			return
+		}
+		http.Redirect(w, r, h.appURL+"/login/2fa#challenge="+url.QueryEscape(challenge), http.StatusFound)
+		return
+	}
+
+	sid, err := h.sessions.New(u.ID, false, authmw.ClientInfo(r))
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+	h.sessions.SetCookie(w, sid)
+	http.Redirect(w, r, h.appURL+"/", http.StatusFound)
+}
+
+func mapErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, oidc.ErrUnknownProvider):
+		response.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, oidc.ErrInvalidState):
+		response.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, user.ErrEmailTaken):
+		response.Error(w, http.StatusConflict, "an account with this email already exists; log in with your password")
+	case errors.Is(err, user.ErrValidation):
+		response.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, user.ErrSuspended):
+		response.Error(w, http.StatusForbidden, err.Error())
+	default:
+		log.Printf("oidc login failed: %v", err)
+		response.Error(w, http.StatusUnauthorized, "login with identity provider failed")
+	}
+}
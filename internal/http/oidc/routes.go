@@ -0,0 +1,22 @@
+package oidc
+
+import (
+	"net/http"
+	"strings"
+)
+
+func Register(mux *http.ServeMux, h *Handler) {
+	const api = "/api/v1"
+
+	mux.HandleFunc("GET "+api+"/auth/oidc/providers", h.Providers)
+	mux.HandleFunc("GET "+api+"/auth/oidc/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/start"):
+			h.Start(w, r)
+		case strings.HasSuffix(r.URL.Path, "/callback"):
+			h.Callback(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
@@ -2,12 +2,14 @@ package order
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
 	"github.com/Gab-Mello/service-finder/internal/http/response"
 	domain "github.com/Gab-Mello/service-finder/internal/order"
+	"github.com/Gab-Mello/service-finder/internal/pagination"
 )
 
 const basePath = "/api/v1/orders/"
@@ -19,6 +21,7 @@ func NewHandler(s *domain.Service) *Handler { return &Handler{svc: s} }
 type requestOrder struct {
 	PostingID  string `json:"postingId"`
 	ProviderID string `json:"providerId"`
+	AddressID  string `json:"addressId"`
 }
 type acceptReq struct {
 	ScheduledAt string `json:"scheduledAt"`
@@ -35,7 +38,7 @@ func (h *Handler) Request(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusBadRequest, "invalid json")
 		return
 	}
-	o, err := h.svc.Request(uid, req.PostingID, req.ProviderID)
+	o, err := h.svc.Request(uid, req.PostingID, req.ProviderID, req.AddressID)
 	if err != nil {
 		response.Error(w, statusFor(err), err.Error())
 		return
@@ -140,7 +143,16 @@ func (h *Handler) ListMine(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	list, err := h.svc.ListMine(uid)
+	page, err := pagination.FromQuery(r.URL.Query())
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	list, err := h.svc.ListMine(uid, page)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		response.InternalError(w, err)
 		return
@@ -152,7 +164,7 @@ func statusFor(err error) int {
 	switch err {
 	case domain.ErrForbidden:
 		return http.StatusForbidden
-	case domain.ErrInvalidState, domain.ErrInvalidFields:
+	case domain.ErrInvalidState, domain.ErrInvalidFields, domain.ErrInvalidAddress:
 		return http.StatusBadRequest
 	case domain.ErrNotFound:
 		return http.StatusNotFound
@@ -6,31 +6,43 @@ import (
 
 	"github.com/Gab-Mello/service-finder/internal/auth"
 	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
+	"github.com/Gab-Mello/service-finder/internal/user"
 )
 
-func Register(mux *http.ServeMux, h *Handler, sessions *auth.SessionManager) {
+func Register(mux *http.ServeMux, h *Handler, sessions *auth.SessionManager, users authmw.UserLoader) {
 	const api = "/api/v1"
+	customer := authmw.WithRole(sessions, users, user.RoleCustomer)
+	provider := authmw.WithRole(sessions, users, user.RoleProvider)
+	member := authmw.WithRole(sessions, users, user.RoleCustomer, user.RoleProvider)
 
-	mux.HandleFunc("POST "+api+"/orders", authmw.WithAuth(sessions, h.Request))
-	mux.HandleFunc("GET "+api+"/orders/mine", authmw.WithAuth(sessions, h.ListMine))
-	mux.HandleFunc("GET "+api+"/orders/", authmw.WithAuth(sessions, h.Get))
+	// every order route is open to API keys with the matching scope
+	read := func(next http.HandlerFunc) http.HandlerFunc {
+		return authmw.WithScope(user.ScopeOrdersRead, next)
+	}
+	write := func(next http.HandlerFunc) http.HandlerFunc {
+		return authmw.WithScope(user.ScopeOrdersWrite, next)
+	}
 
-	mux.HandleFunc("POST "+api+"/orders/", authmw.WithAuth(sessions, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("POST "+api+"/orders", write(customer(h.Request)))
+	mux.HandleFunc("GET "+api+"/orders/mine", read(member(h.ListMine)))
+	mux.HandleFunc("GET "+api+"/orders/", read(member(h.Get)))
+
+	mux.HandleFunc("POST "+api+"/orders/", func(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case strings.HasSuffix(r.URL.Path, "/accept"):
-			h.Accept(w, r)
+			write(provider(h.Accept))(w, r)
 			return
 		case strings.HasSuffix(r.URL.Path, "/start"):
-			h.Start(w, r)
+			write(provider(h.Start))(w, r)
 			return
 		case strings.HasSuffix(r.URL.Path, "/complete"):
-			h.Complete(w, r)
+			write(provider(h.Complete))(w, r)
 			return
 		case strings.HasSuffix(r.URL.Path, "/cancel"):
-			h.Cancel(w, r)
+			write(member(h.Cancel))(w, r)
 			return
 		default:
 			http.NotFound(w, r)
 		}
-	}))
+	})
 }
@@ -2,11 +2,13 @@ package posting
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
 	"github.com/Gab-Mello/service-finder/internal/http/response"
+	"github.com/Gab-Mello/service-finder/internal/pagination"
 	domain "github.com/Gab-Mello/service-finder/internal/posting"
 )
 
@@ -78,7 +80,16 @@ func (h *Handler) ListMine(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	list, err := h.svc.ListMine(pid)
+	page, err := pagination.FromQuery(r.URL.Query())
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	list, err := h.svc.ListMine(pid, page)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		response.InternalError(w, err)
 		return
@@ -98,6 +109,11 @@ func (h *Handler) GetPublic(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
+	page, err := pagination.FromQuery(q)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	p := domain.SearchParams{
 		Query:    q.Get("q"),
 		Category: q.Get("category"),
@@ -105,6 +121,7 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 		District: q.Get("district"),
 		Sort:     q.Get("sort"),
 		Order:    q.Get("order"),
+		Page:     page,
 	}
 	if v := q.Get("price_min"); v != "" {
 		p.PriceMin = parseI64(v)
@@ -115,26 +132,17 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	if v := q.Get("rating_min"); v != "" {
 		p.RatingMin = parseF64(v)
 	}
-	if v := q.Get("limit"); v != "" {
-		p.Limit = parseI(v)
-	}
-	if v := q.Get("offset"); v != "" {
-		p.Offset = parseI(v)
-	}
-
-	items, next := h.svc.Search(p)
-	response.JSON(w, http.StatusOK, map[string]any{
-		"items":       items,
-		"next_offset": next,
-	})
-}
 
-func parseI(s string) int {
-	i, err := strconv.Atoi(s)
+	results, err := h.svc.Search(p)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
-		return 0
+		response.InternalError(w, err)
+		return
 	}
-	return i
+	response.JSON(w, http.StatusOK, results)
 }
 
 func parseI64(s string) int64 {
@@ -155,7 +163,7 @@ func parseF64(s string) float64 {
 
 func statusFor(err error) int {
 	switch err {
-	case domain.ErrForbidden:
+	case domain.ErrForbidden, domain.ErrUnverified:
 		return http.StatusForbidden
 	case domain.ErrNotFound:
 		return http.StatusNotFound
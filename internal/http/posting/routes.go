@@ -6,22 +6,32 @@ import (
 
 	"github.com/Gab-Mello/service-finder/internal/auth"
 	middleware "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
+	"github.com/Gab-Mello/service-finder/internal/user"
 )
 
-func Register(mux *http.ServeMux, h *Handler, sessions *auth.SessionManager) {
+func Register(mux *http.ServeMux, h *Handler, sessions *auth.SessionManager, users middleware.UserLoader) {
 	const api = "/api/v1"
+	provider := middleware.WithRole(sessions, users, user.RoleProvider)
+
+	// routes open to API keys with the matching scope
+	read := func(next http.HandlerFunc) http.HandlerFunc {
+		return middleware.WithScope(user.ScopePostingsRead, next)
+	}
+	write := func(next http.HandlerFunc) http.HandlerFunc {
+		return middleware.WithScope(user.ScopePostingsWrite, next)
+	}
 
 	mux.HandleFunc("GET "+api+"/postings", h.Search)
 	mux.HandleFunc("GET "+api+"/postings/", h.GetPublic)
 
-	mux.HandleFunc("POST "+api+"/postings", middleware.WithAuth(sessions, h.Create))
-	mux.HandleFunc("GET "+api+"/postings/mine", middleware.WithAuth(sessions, h.ListMine))
-	mux.HandleFunc("PATCH "+api+"/postings/", middleware.WithAuth(sessions, h.Update))
-	mux.HandleFunc("POST "+api+"/postings/", middleware.WithAuth(sessions, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("POST "+api+"/postings", write(provider(h.Create)))
+	mux.HandleFunc("GET "+api+"/postings/mine", read(provider(h.ListMine)))
+	mux.HandleFunc("PATCH "+api+"/postings/", write(provider(h.Update)))
+	mux.HandleFunc("POST "+api+"/postings/", write(provider(func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasSuffix(r.URL.Path, "/archive") {
 			h.Archive(w, r)
 			return
 		}
 		http.NotFound(w, r)
-	}))
+	})))
 }
@@ -2,10 +2,13 @@ package review
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
 	"github.com/Gab-Mello/service-finder/internal/http/response"
+	"github.com/Gab-Mello/service-finder/internal/pagination"
 	domain "github.com/Gab-Mello/service-finder/internal/review"
 )
 
@@ -25,6 +28,16 @@ type editReq struct {
 	Comment string `json:"comment"`
 }
 
+// publicReview is a review as anyone can list it, without the client who
+// wrote it or the order it is about.
+type publicReview struct {
+	ProviderID string    `json:"providerId"`
+	Stars      int       `json:"stars"`
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	uid, ok := authmw.UserIDFromContext(r)
 	if !ok {
@@ -66,6 +79,38 @@ func (h *Handler) Edit(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, rv)
 }
 
+// ListForProvider returns the reviews of the provider in the provider query
+// parameter, newest first.
+func (h *Handler) ListForProvider(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	providerID := q.Get("provider")
+	if providerID == "" {
+		response.Error(w, http.StatusBadRequest, "provider is required")
+		return
+	}
+	page, err := pagination.FromQuery(q)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	list, err := h.svc.ListForProvider(providerID, page)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+	out := pagination.Page[publicReview]{Items: make([]publicReview, 0, len(list.Items)), Total: list.Total, NextCursor: list.NextCursor}
+	for _, rv := range list.Items {
+		out.Items = append(out.Items, publicReview{
+			ProviderID: rv.ProviderID, Stars: rv.Stars, Comment: rv.Comment, CreatedAt: rv.CreatedAt, UpdatedAt: rv.UpdatedAt,
+		})
+	}
+	response.JSON(w, http.StatusOK, out)
+}
+
 func statusFor(err error) int {
 	switch err {
 	case domain.ErrForbidden:
@@ -0,0 +1,68 @@
+package review
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/order"
+	domain "github.com/Gab-Mello/service-finder/internal/review"
+)
+
+func TestListForProviderHidesClientsAndOrders(t *testing.T) {
+	orders := order.NewRepository()
+	svc := domain.NewService(domain.NewRepository(), orders, nil)
+	for i := range 3 {
+		o := &order.Order{ID: fmt.Sprintf("order-%d", i), ClientID: "client-secret", ProviderID: "prov1", Status: order.StatusCompleted}
+		if err := orders.Create(o); err != nil {
+			t.Fatalf("Create order: %v", err)
+		}
+		if _, err := svc.Create("client-secret", o.ID, 5, "great"); err != nil {
+			t.Fatalf("Create review: %v", err)
+		}
+	}
+	h := NewHandler(svc)
+
+	w := httptest.NewRecorder()
+	h.ListForProvider(w, httptest.NewRequest(http.MethodGet, "/api/v1/reviews?provider=prov1&limit=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	var page struct {
+		Items      []map[string]any `json:"items"`
+		NextCursor string           `json:"nextCursor"`
+	}
+	if err := json.Unmarshal([]byte(body), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Items) != 2 || page.NextCursor == "" {
+		t.Fatalf("got %d items and cursor %q, want 2 and a cursor", len(page.Items), page.NextCursor)
+	}
+	cursor, err := base64.RawURLEncoding.DecodeString(page.NextCursor)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	for _, leak := range []string{"client-secret", "order-", "clientId", "orderId"} {
+		if strings.Contains(body, leak) {
+			t.Errorf("response contains %q: %s", leak, body)
+		}
+		if strings.Contains(string(cursor), leak) {
+			t.Errorf("cursor contains %q: %s", leak, cursor)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	h.ListForProvider(w, httptest.NewRequest(http.MethodGet, "/api/v1/reviews?provider=prov1&limit=2&cursor="+page.NextCursor, nil))
+	page.Items, page.NextCursor = nil, ""
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Items) != 1 || page.NextCursor != "" {
+		t.Fatalf("second page: got %d items and cursor %q, want the last one", len(page.Items), page.NextCursor)
+	}
+}
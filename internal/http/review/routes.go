@@ -5,11 +5,14 @@ import (
 
 	"github.com/Gab-Mello/service-finder/internal/auth"
 	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
+	"github.com/Gab-Mello/service-finder/internal/user"
 )
 
-func Register(mux *http.ServeMux, h *Handler, sessions *auth.SessionManager) {
+func Register(mux *http.ServeMux, h *Handler, sessions *auth.SessionManager, users authmw.UserLoader) {
 	const api = "/api/v1"
+	customer := authmw.WithRole(sessions, users, user.RoleCustomer)
 
-	mux.HandleFunc("POST "+api+"/reviews", authmw.WithAuth(sessions, h.Create))
-	mux.HandleFunc("PATCH "+api+"/reviews/", authmw.WithAuth(sessions, h.Edit))
+	mux.HandleFunc("GET "+api+"/reviews", h.ListForProvider)
+	mux.HandleFunc("POST "+api+"/reviews", customer(h.Create))
+	mux.HandleFunc("PATCH "+api+"/reviews/", customer(h.Edit))
 }
@@ -8,8 +8,12 @@ import (
 	"github.com/Gab-Mello/service-finder/internal/order"
 	"github.com/Gab-Mello/service-finder/internal/posting"
 
+	"github.com/Gab-Mello/service-finder/internal/audit"
 	"github.com/Gab-Mello/service-finder/internal/auth"
+	audithttp "github.com/Gab-Mello/service-finder/internal/http/audit"
+	oidchttp "github.com/Gab-Mello/service-finder/internal/http/oidc"
 	postinghttp "github.com/Gab-Mello/service-finder/internal/http/posting"
+	"github.com/Gab-Mello/service-finder/internal/oidc"
 	"github.com/Gab-Mello/service-finder/internal/user"
 
 	reviewhttp "github.com/Gab-Mello/service-finder/internal/http/review"
@@ -18,20 +22,28 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 )
 
-func RegisterAll(mux *http.ServeMux, sessions *auth.SessionManager, userSvc *user.Service, postingSvc *posting.Service, orderSvc *order.Service, reviewSvc *reviewsvc.Service) {
+func RegisterAll(mux *http.ServeMux, sessions *auth.SessionManager, userSvc *user.Service, postingSvc *posting.Service, orderSvc *order.Service, reviewSvc *reviewsvc.Service, auditLog *audit.Log, oidcClient *oidc.Client, appURL string) {
 
 	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 
 	uh := userhttp.NewHandler(userSvc, sessions)
-	userhttp.Register(mux, uh, sessions)
+	userhttp.Register(mux, uh, sessions, userSvc)
 
 	ph := postinghttp.NewHandler(postingSvc)
-	postinghttp.Register(mux, ph, sessions)
+	postinghttp.Register(mux, ph, sessions, userSvc)
 
 	oh := orderhttp.NewHandler(orderSvc)
-	orderhttp.Register(mux, oh, sessions)
+	orderhttp.Register(mux, oh, sessions, userSvc)
 
 	rh := reviewhttp.NewHandler(reviewSvc)
-	reviewhttp.Register(mux, rh, sessions)
+	reviewhttp.Register(mux, rh, sessions, userSvc)
+
+	ah := audithttp.NewHandler(auditLog)
+	audithttp.Register(mux, ah, sessions, userSvc)
+
+	if oidcClient != nil {
+		oh := oidchttp.NewHandler(oidcClient, userSvc, sessions, appURL)
+		oidchttp.Register(mux, oh)
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Gab-Mello/service-finder/internal/auth"
 	"github.com/Gab-Mello/service-finder/internal/http/middleware"
 )
 
@@ -12,10 +13,12 @@ func NewServer() *http.ServeMux {
 	return http.NewServeMux()
 }
 
-func Listen(addr string, handler *http.ServeMux) error {
+func Listen(addr string, handler *http.ServeMux, sessions *auth.SessionManager, audit middleware.Auditor) error {
+	h := middleware.CSRF(sessions)(handler)
+	h = middleware.AuditImpersonation(sessions, audit)(h)
 	srv := &http.Server{
 		Addr:              addr,
-		Handler:           middleware.CORSWithCreds(withLogging(handler)),
+		Handler:           middleware.CORSWithCreds(withLogging(h)),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 	return srv.ListenAndServe()
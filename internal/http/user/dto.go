@@ -9,6 +9,29 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Remember bool   `json:"remember"`
+	// Tokens asks for a bearer access/refresh pair instead of a cookie.
+	Tokens bool `json:"tokens"`
+}
+type TwoFactorLoginRequest struct {
+	Challenge string `json:"challenge"`
+	// Code is a current TOTP code or one of the recovery codes.
+	Code     string `json:"code"`
+	Remember bool   `json:"remember"`
+	Tokens   bool   `json:"tokens"`
+}
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// PasswordRequest confirms a sensitive change. Accounts without a password
+// send a two-factor code instead, or nothing right after logging in.
+type PasswordRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
 }
 type ProviderProfileRequest struct {
 	Bio       string `json:"bio"`
@@ -17,3 +40,51 @@ type ProviderProfileRequest struct {
 	City      string `json:"city"`
 	District  string `json:"district"`
 }
+type CustomerProfileRequest struct {
+	Phone string `json:"phone"`
+}
+type AddressRequest struct {
+	Label      string `json:"label"`
+	Street     string `json:"street"`
+	Number     string `json:"number"`
+	District   string `json:"district"`
+	City       string `json:"city"`
+	PostalCode string `json:"postalCode"`
+	Notes      string `json:"notes"`
+}
+
+type SuspendRequest struct {
+	Reason string `json:"reason"`
+	Until  string `json:"until"` // RFC3339; empty suspends until lifted
+}
+type ImpersonateRequest struct {
+	Reason string `json:"reason"`
+}
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expiresAt"` // RFC3339; empty never expires
+}
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+type UpdateMeRequest struct {
+	Name string `json:"name"`
+}
+type ChangeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	Code            string `json:"code"`
+	NewPassword     string `json:"newPassword"`
+}
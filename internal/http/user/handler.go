@@ -3,14 +3,25 @@ package user
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/Gab-Mello/service-finder/internal/auth"
 	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
 	"github.com/Gab-Mello/service-finder/internal/http/response"
+	"github.com/Gab-Mello/service-finder/internal/ports"
 	domain "github.com/Gab-Mello/service-finder/internal/user"
 )
 
+const (
+	sessionsPath   = "/api/v1/me/sessions/"
+	adminUsersPath = "/api/v1/admin/users/"
+	addressesPath  = "/api/v1/customers/addresses/"
+	apiKeysPath    = "/api/v1/me/api-keys/"
+)
+
 type Handler struct {
 	svc      *domain.Service
 	sessions *auth.SessionManager
@@ -32,7 +43,8 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	response.JSON(w, http.StatusCreated, map[string]any{
-		"id": u.ID, "name": u.Name, "email": u.Email, "role": u.Role,
+		"id": u.ID, "name": u.Name, "email": u.Email, "role": u.Role, "roles": u.Capabilities(),
+		"emailVerified": u.EmailVerified,
 	})
 }
 
@@ -42,50 +54,415 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		response.Error(w, http.StatusBadRequest, "invalid json")
 		return
 	}
-	u, err := h.svc.Authenticate(req.Email, req.Password)
-	if err != nil {
+	u, err := h.svc.Authenticate(req.Email, req.Password, authmw.ClientInfo(r).IP)
+	switch {
+	case errors.Is(err, domain.ErrTooManyRequests), errors.Is(err, domain.ErrSuspended):
+		mapErr(w, err)
+		return
+	case errors.Is(err, domain.ErrUnauthorized):
 		response.Error(w, http.StatusUnauthorized, "invalid email or password")
 		return
+	case err != nil:
+		response.InternalError(w, err)
+		return
 	}
 
-	sid, err := h.sessions.New(u.ID)
-	if err != nil {
+	if u.TwoFactor.Enabled {
+		challenge, err := h.svc.NewLoginChallenge(u.ID)
+		if err != nil {
+			response.InternalError(w, err)
+			return
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"mfaRequired": true, "challenge": challenge})
+		return
+	}
+	h.signIn(w, r, u, req.Remember, req.Tokens)
+}
+
+// LoginTwoFactor is the second login step for accounts with two-factor
+// enabled: it trades the challenge from Login plus a code for a session.
+func (h *Handler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Challenge == "" {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.CompleteLoginChallenge(req.Challenge, req.Code, authmw.ClientInfo(r).IP)
+	switch {
+	case errors.Is(err, domain.ErrTooManyRequests):
+		mapErr(w, err)
+		return
+	case errors.Is(err, domain.ErrInvalidToken):
+		response.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, domain.ErrUnauthorized):
+		response.Error(w, http.StatusUnauthorized, "invalid code")
+		return
+	case err != nil:
 		response.InternalError(w, err)
 		return
 	}
-	h.sessions.SetCookie(w, sid)
+	h.signIn(w, r, u, req.Remember, req.Tokens)
+}
 
-	response.JSON(w, http.StatusOK, map[string]any{
-		"userId": u.ID, "name": u.Name, "role": u.Role,
-	})
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	pair, err := h.sessions.Refresh(req.RefreshToken, authmw.ClientInfo(r))
+	switch {
+	case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrTokenReused):
+		response.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	case err != nil:
+		response.InternalError(w, err)
+		return
+	}
+
+	resp := map[string]any{}
+	addTokens(resp, pair)
+	response.JSON(w, http.StatusOK, resp)
 }
 
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
-	if c, err := r.Cookie("sid"); err == nil {
-		h.sessions.Delete(c.Value)
+	if token, ok := authmw.Token(r); ok {
+		h.sessions.Delete(token)
 	}
 	h.sessions.ClearCookie(w)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.RequestPasswordReset(req.Email); err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusAccepted, map[string]string{
+		"status": "if the email is registered, a reset link has been sent",
+	})
+}
+
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.ResetPassword(req.Token, req.Password); err != nil {
+		mapErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.VerifyEmail(req.Token)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{"id": u.ID, "emailVerified": u.EmailVerified})
+}
+
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.ResendVerification(uid); err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusAccepted, map[string]string{"status": "verification email sent"})
+}
+
 func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	u, ok := authmw.PrincipalFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	resp := meResponse(u)
+	if adminID, ok := authmw.ImpersonatorFromContext(r); ok {
+		resp["impersonating"] = true
+		resp["impersonatedBy"] = adminID
+	}
+	// cookie clients fetch their CSRF token here after a page reload
+	if c, err := r.Cookie("sid"); err == nil && r.Header.Get("Authorization") == "" {
+		resp["csrfToken"] = h.sessions.CSRFToken(c.Value)
+	}
+	response.JSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req UpdateMeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.UpdateName(uid, req.Name)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, meResponse(u))
+}
+
+func (h *Handler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.RequestEmailChange(uid, reauth(r, req.Password, req.Code), req.Email); err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusAccepted, map[string]string{"status": "confirmation sent to the new address"})
+}
+
+func (h *Handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.ConfirmEmailChange(req.Token)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{"id": u.ID, "email": u.Email, "emailVerified": u.EmailVerified})
+}
+
+// ChangePassword ends every session, this one included; the client has to
+// log in again with the new password.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.ChangePassword(uid, reauth(r, req.CurrentPassword, req.Code), req.NewPassword); err != nil {
+		mapErr(w, err)
+		return
+	}
+	h.sessions.ClearCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteMe(w http.ResponseWriter, r *http.Request) {
 	uid, ok := authmw.UserIDFromContext(r)
 	if !ok {
 		response.Error(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
+	var req PasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.DeleteAccount(uid, reauth(r, req.Password, req.Code)); err != nil {
+		mapErr(w, err)
+		return
+	}
+	h.sessions.ClearCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	u, err := h.svc.ByID(uid)
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	secret, uri, err := h.svc.EnrollTwoFactor(uid)
 	if err != nil {
 		mapErr(w, err)
 		return
 	}
+	response.JSON(w, http.StatusOK, map[string]string{"secret": secret, "otpauthUri": uri})
+}
 
-	resp := map[string]any{"id": u.ID, "name": u.Name, "email": u.Email, "role": u.Role}
-	if u.Provider != nil {
-		resp["provider"] = u.Provider
+func (h *Handler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
 	}
-	response.JSON(w, http.StatusOK, resp)
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	codes, err := h.svc.ConfirmTwoFactor(uid, req.Code)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{"twoFactorEnabled": true, "recoveryCodes": codes})
+}
+
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req PasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := h.svc.DisableTwoFactor(uid, reauth(r, req.Password, req.Code)); err != nil {
+		mapErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	current, _ := authmw.Token(r)
+
+	list, err := h.sessions.List(uid, current)
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	id := response.PathParam(r.URL.Path, sessionsPath, "")
+
+	err := h.sessions.Revoke(uid, id)
+	switch {
+	case errors.Is(err, auth.ErrSessionNotFound):
+		response.Error(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		response.InternalError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAllSessions logs the user out everywhere, including this request's
+// session.
+func (h *Handler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.sessions.RevokeAll(uid); err != nil {
+		response.InternalError(w, err)
+		return
+	}
+	h.sessions.ClearCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	list, err := h.svc.APIKeys(uid)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, list)
+}
+
+// CreateAPIKey issues a key for integrations. The key is only shown in
+// this response.
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid expiresAt (RFC3339)")
+			return
+		}
+		expiresAt = &t
+	}
+	scopes := make([]domain.Scope, len(req.Scopes))
+	for i, sc := range req.Scopes {
+		scopes[i] = domain.Scope(sc)
+	}
+
+	k, key, err := h.svc.CreateAPIKey(uid, req.Name, scopes, expiresAt)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusCreated, map[string]any{"apiKey": k, "key": key})
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	id := response.PathParam(r.URL.Path, apiKeysPath, "")
+	if err := h.svc.RevokeAPIKey(uid, id); err != nil {
+		mapErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *Handler) UpdateProviderProfile(w http.ResponseWriter, r *http.Request) {
@@ -111,16 +488,306 @@ func (h *Handler) UpdateProviderProfile(w http.ResponseWriter, r *http.Request)
 	response.JSON(w, http.StatusOK, map[string]any{"status": "ok", "provider": u.Provider})
 }
 
+// Export returns the user's personal data as a JSON download.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	data, err := h.svc.ExportData(uid)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	current, _ := authmw.Token(r)
+	if data["sessions"], err = h.sessions.List(uid, current); err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="service-finder-export.json"`)
+	response.JSON(w, http.StatusOK, data)
+}
+
+// BecomeProvider onboards the current customer as a provider.
+func (h *Handler) BecomeProvider(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req ProviderProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.BecomeProvider(uid, domain.ProviderProfile{
+		Bio: req.Bio, Phone: req.Phone, Expertise: req.Expertise, City: req.City, District: req.District,
+	})
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, meResponse(u))
+}
+
+func (h *Handler) UpdateCustomerProfile(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CustomerProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.UpdateCustomerProfile(uid, req.Phone)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, map[string]any{"status": "ok", "customer": u.Customer})
+}
+
+func (h *Handler) ListAddresses(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	list, err := h.svc.Addresses(uid)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) AddAddress(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req AddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	a, err := h.svc.AddAddress(uid, addressFromRequest("", req))
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusCreated, a)
+}
+
+func (h *Handler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	id := response.PathParam(r.URL.Path, addressesPath, "")
+
+	var req AddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	a, err := h.svc.UpdateAddress(uid, addressFromRequest(id, req))
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, a)
+}
+
+func (h *Handler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	uid, ok := authmw.UserIDFromContext(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	id := response.PathParam(r.URL.Path, addressesPath, "")
+
+	if err := h.svc.DeleteAddress(uid, id); err != nil {
+		mapErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// signIn starts a cookie session, or issues a bearer token pair when the
+// client asked for tokens.
+func (h *Handler) signIn(w http.ResponseWriter, r *http.Request, u *domain.User, remember, tokens bool) {
+	resp := map[string]any{"userId": u.ID, "name": u.Name, "role": u.Role, "roles": u.Capabilities()}
+	if tokens {
+		pair, err := h.sessions.NewTokenPair(u.ID, authmw.ClientInfo(r))
+		if err != nil {
+			response.InternalError(w, err)
+			return
+		}
+		addTokens(resp, pair)
+		response.JSON(w, http.StatusOK, resp)
+		return
+	}
+
+	sid, err := h.sessions.New(u.ID, remember, authmw.ClientInfo(r))
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+	h.sessions.SetCookie(w, sid)
+	resp["csrfToken"] = h.sessions.CSRFToken(sid)
+
+	response.JSON(w, http.StatusOK, resp)
+}
+
+// UnlockLogin lets an admin lift a login lockout before it expires.
+func (h *Handler) UnlockLogin(w http.ResponseWriter, r *http.Request) {
+	id := response.PathParam(r.URL.Path, adminUsersPath, "/unlock")
+	if err := h.svc.UnlockLogin(id); err != nil {
+		mapErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Suspend lets an admin block an account, optionally until a given time.
+func (h *Handler) Suspend(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := authmw.UserIDFromContext(r)
+	id := response.PathParam(r.URL.Path, adminUsersPath, "/suspend")
+
+	var req SuspendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	var until *time.Time
+	if req.Until != "" {
+		t, err := time.Parse(time.RFC3339, req.Until)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid until (RFC3339)")
+			return
+		}
+		until = &t
+	}
+
+	u, err := h.svc.Suspend(adminID, id, req.Reason, until)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	response.JSON(w, http.StatusOK, map[string]any{"id": u.ID, "suspension": u.Suspension})
+}
+
+// Unsuspend lets an admin lift a suspension.
+func (h *Handler) Unsuspend(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := authmw.UserIDFromContext(r)
+	id := response.PathParam(r.URL.Path, adminUsersPath, "/unsuspend")
+	if _, err := h.svc.Unsuspend(adminID, id); err != nil {
+		mapErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Impersonate lets an admin act as a user for support. It returns a bearer
+// token for a short session bound to both accounts; everything done with it
+// is audited.
+func (h *Handler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := authmw.UserIDFromContext(r)
+	id := response.PathParam(r.URL.Path, adminUsersPath, "/impersonate")
+
+	var req ImpersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	u, err := h.svc.Impersonate(adminID, id, req.Reason)
+	if err != nil {
+		mapErr(w, err)
+		return
+	}
+	token, err := h.sessions.NewImpersonation(adminID, u.ID, authmw.ClientInfo(r))
+	if err != nil {
+		response.InternalError(w, err)
+		return
+	}
+	response.JSON(w, http.StatusCreated, map[string]any{
+		"accessToken": token,
+		"tokenType":   "Bearer",
+		"user":        meResponse(u),
+	})
+}
+
+func addressFromRequest(id string, req AddressRequest) ports.Address {
+	return ports.Address{
+		ID: id, Label: req.Label, Street: req.Street, Number: req.Number,
+		District: req.District, City: req.City, PostalCode: req.PostalCode, Notes: req.Notes,
+	}
+}
+
+func meResponse(u *domain.User) map[string]any {
+	resp := map[string]any{
+		"id": u.ID, "name": u.Name, "email": u.Email, "role": u.Role, "roles": u.Capabilities(),
+		"emailVerified": u.EmailVerified, "twoFactorEnabled": u.TwoFactor.Enabled,
+	}
+	if u.PendingEmail != "" {
+		resp["pendingEmail"] = u.PendingEmail
+	}
+	if u.Provider != nil {
+		resp["provider"] = u.Provider
+	}
+	if u.Customer != nil {
+		resp["customer"] = u.Customer
+	}
+	return resp
+}
+
+func addTokens(resp map[string]any, pair *auth.TokenPair) {
+	resp["accessToken"] = pair.AccessToken
+	resp["refreshToken"] = pair.RefreshToken
+	resp["tokenType"] = "Bearer"
+	resp["expiresIn"] = int(pair.ExpiresIn.Seconds())
+}
+
+// reauth gathers what the request offers to confirm a sensitive change.
+func reauth(r *http.Request, password, code string) domain.Reauth {
+	loginAt, _ := authmw.LoginTimeFromContext(r)
+	return domain.Reauth{Password: password, Code: code, LoginAt: loginAt, IP: authmw.ClientInfo(r).IP}
+}
+
 func mapErr(w http.ResponseWriter, err error) {
+	var retry *domain.RetryAfterError
 	switch {
+	case errors.As(err, &retry):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Wait.Seconds()))))
+		response.Error(w, http.StatusTooManyRequests, err.Error())
 	case errors.Is(err, domain.ErrEmailTaken):
 		response.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrSuspended), errors.Is(err, domain.ErrReauthRequired):
+		response.Error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrAlreadyProvider):
+		response.Error(w, http.StatusConflict, err.Error())
 	case errors.Is(err, domain.ErrUnauthorized):
 		response.Error(w, http.StatusUnauthorized, err.Error())
-	case errors.Is(err, domain.ErrNotFound):
+	case errors.Is(err, domain.ErrNotFound), errors.Is(err, domain.ErrAddressNotFound), errors.Is(err, domain.ErrAPIKeyNotFound):
 		response.Error(w, http.StatusNotFound, err.Error())
-	case errors.Is(err, domain.ErrValidation):
+	case errors.Is(err, domain.ErrValidation), errors.Is(err, domain.ErrInvalidToken):
 		response.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrMailerDisabled):
+		response.Error(w, http.StatusServiceUnavailable, err.Error())
 	default:
 		response.InternalError(w, err)
 	}
@@ -2,16 +2,70 @@ package user
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/Gab-Mello/service-finder/internal/auth"
 	authmw "github.com/Gab-Mello/service-finder/internal/http/middleware/auth"
+	"github.com/Gab-Mello/service-finder/internal/user"
 )
 
-func Register(mux *http.ServeMux, h *Handler, sessions *auth.SessionManager) {
+func Register(mux *http.ServeMux, h *Handler, sessions *auth.SessionManager, users authmw.UserLoader) {
 	const api = "/api/v1"
+	anyone := authmw.WithRole(sessions, users)
+	provider := authmw.WithRole(sessions, users, user.RoleProvider)
+	customer := authmw.WithRole(sessions, users, user.RoleCustomer)
+	admin := authmw.WithRole(sessions, users, user.RoleAdmin)
+	// credentials, the account itself and its data export stay out of reach
+	// of admins impersonating the user
+	sensitive := func(next http.HandlerFunc) http.HandlerFunc {
+		return authmw.WithAuth(sessions, users, authmw.NotImpersonating(next))
+	}
+
 	mux.HandleFunc("POST "+api+"/users", h.Register)
 	mux.HandleFunc("POST "+api+"/login", h.Login)
+	mux.HandleFunc("POST "+api+"/login/2fa", h.LoginTwoFactor)
 	mux.HandleFunc("POST "+api+"/logout", h.Logout)
-	mux.HandleFunc("GET "+api+"/me", authmw.WithAuth(sessions, h.Me))
-	mux.HandleFunc("PATCH "+api+"/providers/profile", authmw.WithAuth(sessions, h.UpdateProviderProfile))
+	mux.HandleFunc("POST "+api+"/token/refresh", h.RefreshToken)
+	mux.HandleFunc("POST "+api+"/password/forgot", h.ForgotPassword)
+	mux.HandleFunc("POST "+api+"/password/reset", h.ResetPassword)
+	mux.HandleFunc("POST "+api+"/email/verify", h.VerifyEmail)
+	mux.HandleFunc("POST "+api+"/email/verify/resend", authmw.WithAuth(sessions, users, h.ResendVerification))
+	mux.HandleFunc("POST "+api+"/email/change/confirm", h.ConfirmEmailChange)
+	mux.HandleFunc("GET "+api+"/me", anyone(h.Me))
+	mux.HandleFunc("PATCH "+api+"/me", authmw.WithAuth(sessions, users, h.UpdateMe))
+	mux.HandleFunc("DELETE "+api+"/me", sensitive(h.DeleteMe))
+	mux.HandleFunc("GET "+api+"/me/export", sensitive(h.Export))
+	mux.HandleFunc("POST "+api+"/me/email", sensitive(h.ChangeEmail))
+	mux.HandleFunc("POST "+api+"/me/password", sensitive(h.ChangePassword))
+	mux.HandleFunc("POST "+api+"/me/2fa/enroll", sensitive(h.EnrollTwoFactor))
+	mux.HandleFunc("POST "+api+"/me/2fa/confirm", sensitive(h.ConfirmTwoFactor))
+	mux.HandleFunc("DELETE "+api+"/me/2fa", sensitive(h.DisableTwoFactor))
+	mux.HandleFunc("GET "+api+"/me/sessions", authmw.WithAuth(sessions, users, h.ListSessions))
+	mux.HandleFunc("DELETE "+api+"/me/sessions", authmw.WithAuth(sessions, users, h.RevokeAllSessions))
+	mux.HandleFunc("DELETE "+api+"/me/sessions/", authmw.WithAuth(sessions, users, h.RevokeSession))
+	mux.HandleFunc("GET "+api+"/me/api-keys", authmw.WithAuth(sessions, users, h.ListAPIKeys))
+	mux.HandleFunc("POST "+api+"/me/api-keys", sensitive(h.CreateAPIKey))
+	mux.HandleFunc("DELETE "+api+"/me/api-keys/", authmw.WithAuth(sessions, users, h.RevokeAPIKey))
+	mux.HandleFunc("POST "+api+"/me/provider", customer(h.BecomeProvider))
+	mux.HandleFunc("PATCH "+api+"/providers/profile", provider(h.UpdateProviderProfile))
+	mux.HandleFunc("PATCH "+api+"/customers/profile", customer(h.UpdateCustomerProfile))
+	mux.HandleFunc("GET "+api+"/customers/addresses", customer(h.ListAddresses))
+	mux.HandleFunc("POST "+api+"/customers/addresses", customer(h.AddAddress))
+	mux.HandleFunc("PATCH "+api+"/customers/addresses/", customer(h.UpdateAddress))
+	mux.HandleFunc("DELETE "+api+"/customers/addresses/", customer(h.DeleteAddress))
+
+	mux.HandleFunc("POST "+api+"/admin/users/", admin(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/unlock"):
+			h.UnlockLogin(w, r)
+		case strings.HasSuffix(r.URL.Path, "/suspend"):
+			h.Suspend(w, r)
+		case strings.HasSuffix(r.URL.Path, "/unsuspend"):
+			h.Unsuspend(w, r)
+		case strings.HasSuffix(r.URL.Path, "/impersonate"):
+			h.Impersonate(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
 }
@@ -0,0 +1,53 @@
+package user
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+	domain "github.com/Gab-Mello/service-finder/internal/user"
+)
+
+func TestExportRefusesImpersonation(t *testing.T) {
+	svc := domain.NewService(domain.NewRepository(), nil, nil, nil)
+	u, err := svc.Register("Ana", "ana@example.com", "secret123", "customer")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	sessions := auth.NewSessionManager(nil, auth.SessionOptions{
+		IdleTimeout: time.Hour, MaxLifetime: time.Hour, ImpersonationTTL: time.Hour,
+	})
+	t.Cleanup(sessions.Close)
+	own, err := sessions.New(u.ID, false, auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	impersonation, err := sessions.NewImpersonation("admin", u.ID, auth.ClientInfo{})
+	if err != nil {
+		t.Fatalf("NewImpersonation: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	Register(mux, NewHandler(svc, sessions), sessions, svc)
+
+	for _, tt := range []struct {
+		name string
+		sid  string
+		want int
+	}{
+		{"OwnSession", own, http.StatusOK},
+		{"Impersonation", impersonation, http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/me/export", nil)
+			r.AddCookie(&http.Cookie{Name: "sid", Value: tt.sid})
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
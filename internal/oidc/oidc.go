@@ -0,0 +1,207 @@
+// Package oidc implements the OpenID Connect authorization code flow with
+// PKCE against configurable issuers.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+// flowTTL bounds how long a user may take at the identity provider.
+const flowTTL = 10 * time.Minute
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	ErrInvalidState    = errors.New("invalid or expired login state")
+)
+
+type Config struct {
+	// Name identifies the provider in URLs, e.g. "google".
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is this API's callback URL registered at the provider.
+	RedirectURL string
+}
+
+type Provider struct {
+	name     string
+	issuer   string
+	oauth    oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+// NewProvider fetches the issuer's discovery document.
+func NewProvider(ctx context.Context, cfg Config) (*Provider, error) {
+	p, err := gooidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover issuer %s: %w", cfg.Issuer, err)
+	}
+	return &Provider{
+		name:   cfg.Name,
+		issuer: cfg.Issuer,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     p.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       []string{gooidc.ScopeOpenID, "email", "profile"},
+		},
+		verifier: p.Verifier(&gooidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+func (p *Provider) Name() string { return p.name }
+
+// Flow is the state of one login between the redirect to the provider and
+// the callback.
+type Flow struct {
+	State     string
+	Provider  string
+	Nonce     string
+	Verifier  string // PKCE code verifier
+	Role      string // role for an account created by this login
+	ExpiresAt time.Time
+}
+
+// Client holds the configured providers and the logins in progress.
+type Client struct {
+	mu        sync.Mutex
+	providers map[string]*Provider
+	flows     map[string]Flow
+	now       func() time.Time
+}
+
+func NewClient(providers ...*Provider) *Client {
+	c := &Client{
+		providers: make(map[string]*Provider),
+		flows:     make(map[string]Flow),
+		now:       time.Now,
+	}
+	for _, p := range providers {
+		c.providers[p.name] = p
+	}
+	return c
+}
+
+// Providers returns the names of the configured providers.
+func (c *Client) Providers() []string {
+	out := make([]string, 0, len(c.providers))
+	for name := range c.providers {
+		out = append(out, name)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Start begins a login at provider and returns the flow, whose State must
+// come back in the callback, and the URL to send the browser to.
+func (c *Client) Start(provider, role string) (Flow, string, error) {
+	p, ok := c.providers[provider]
+	if !ok {
+		return Flow{}, "", ErrUnknownProvider
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return Flow{}, "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return Flow{}, "", err
+	}
+	f := Flow{
+		State:     state,
+		Provider:  provider,
+		Nonce:     nonce,
+		Verifier:  oauth2.GenerateVerifier(),
+		Role:      role,
+		ExpiresAt: c.now().Add(flowTTL),
+	}
+
+	c.mu.Lock()
+	c.sweep()
+	c.flows[state] = f
+	c.mu.Unlock()
+
+	url := p.oauth.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(f.Verifier))
+	return f, url, nil
+}
+
+// Finish consumes the flow for state, redeems code and verifies the ID
+// token. The flow is single-use whether or not the exchange succeeds.
+func (c *Client) Finish(ctx context.Context, provider, state, code string) (Flow, user.ExternalProfile, error) {
+	c.mu.Lock()
+	f, ok := c.flows[state]
+	delete(c.flows, state)
+	c.mu.Unlock()
+	if !ok || f.Provider != provider || c.now().After(f.ExpiresAt) {
+		return Flow{}, user.ExternalProfile{}, ErrInvalidState
+	}
+	p, ok := c.providers[provider]
+	if !ok {
+		return Flow{}, user.ExternalProfile{}, ErrUnknownProvider
+	}
+
+	tok, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(f.Verifier))
+	if err != nil {
+		return Flow{}, user.ExternalProfile{}, fmt.Errorf("failed to redeem authorization code: %w", err)
+	}
+	raw, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return Flow{}, user.ExternalProfile{}, errors.New("token response has no id_token")
+	}
+	idt, err := p.verifier.Verify(ctx, raw)
+	if err != nil {
+		return Flow{}, user.ExternalProfile{}, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if idt.Nonce != f.Nonce {
+		return Flow{}, user.ExternalProfile{}, errors.New("invalid id_token: nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idt.Claims(&claims); err != nil {
+		return Flow{}, user.ExternalProfile{}, fmt.Errorf("invalid id_token claims: %w", err)
+	}
+	return f, user.ExternalProfile{
+		Issuer:        idt.Issuer,
+		Subject:       idt.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// sweep drops abandoned flows. c.mu must be held.
+func (c *Client) sweep() {
+	now := c.now()
+	for state, f := range c.flows {
+		if now.After(f.ExpiresAt) {
+			delete(c.flows, state)
+		}
+	}
+}
+
+func randomString() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
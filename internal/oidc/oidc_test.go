@@ -0,0 +1,11 @@
+package oidc_test
+
+import (
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/oidc/oidctest"
+)
+
+func TestFlow(t *testing.T) {
+	oidctest.TestFlow(t)
+}
@@ -0,0 +1,173 @@
+package oidctest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/oidc"
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+const redirectURL = "http://api.test/api/v1/auth/oidc/mock/callback"
+
+// TestFlow runs the authorization code flow against a fresh IdP, covering
+// the happy path, forged or replayed callbacks, and account linking.
+func TestFlow(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+
+	newClient := func(t *testing.T) (*IdP, *oidc.Client) {
+		idp := NewIdP()
+		t.Cleanup(idp.Close)
+		p, err := oidc.NewProvider(ctx, oidc.Config{
+			Name: "mock", Issuer: idp.Issuer(), ClientID: ClientID, ClientSecret: ClientSecret, RedirectURL: redirectURL,
+		})
+		if err != nil {
+			t.Fatalf("NewProvider: %v", err)
+		}
+		return idp, oidc.NewClient(p)
+	}
+
+	t.Run("Login", func(t *testing.T) {
+		_, c := newClient(t)
+		f, authURL, err := c.Start("mock", "provider")
+		if err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		code, state := authorize(t, authURL)
+		if state != f.State {
+			t.Fatalf("state = %q, want %q", state, f.State)
+		}
+
+		got, profile, err := c.Finish(ctx, "mock", state, code)
+		if err != nil {
+			t.Fatalf("Finish: %v", err)
+		}
+		if got.Role != "provider" {
+			t.Errorf("flow role = %q, want provider", got.Role)
+		}
+		if profile.Subject != "sub-1" || profile.Email != "ana@example.com" || !profile.EmailVerified || profile.Name != "Ana" {
+			t.Errorf("unexpected profile %+v", profile)
+		}
+	})
+
+	t.Run("UnknownProvider", func(t *testing.T) {
+		_, c := newClient(t)
+		if _, _, err := c.Start("other", ""); !errors.Is(err, oidc.ErrUnknownProvider) {
+			t.Fatalf("Start = %v, want ErrUnknownProvider", err)
+		}
+	})
+
+	t.Run("ForgedState", func(t *testing.T) {
+		_, c := newClient(t)
+		_, authURL, _ := c.Start("mock", "")
+		code, _ := authorize(t, authURL)
+		if _, _, err := c.Finish(ctx, "mock", "forged", code); !errors.Is(err, oidc.ErrInvalidState) {
+			t.Fatalf("Finish = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("ReplayedState", func(t *testing.T) {
+		_, c := newClient(t)
+		_, authURL, _ := c.Start("mock", "")
+		code, state := authorize(t, authURL)
+		if _, _, err := c.Finish(ctx, "mock", state, code); err != nil {
+			t.Fatalf("Finish: %v", err)
+		}
+		if _, _, err := c.Finish(ctx, "mock", state, code); !errors.Is(err, oidc.ErrInvalidState) {
+			t.Fatalf("second Finish = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("InjectedCode", func(t *testing.T) {
+		// a code obtained in another flow fails PKCE for this one
+		_, c := newClient(t)
+		_, attackerURL, _ := c.Start("mock", "")
+		stolen, _ := authorize(t, attackerURL)
+		_, victimURL, _ := c.Start("mock", "")
+		_, state := authorize(t, victimURL)
+		if _, _, err := c.Finish(ctx, "mock", state, stolen); err == nil {
+			t.Fatal("Finish accepted a code issued for another flow")
+		}
+	})
+
+	t.Run("NonceMismatch", func(t *testing.T) {
+		idp, c := newClient(t)
+		idp.TamperNonce("replayed")
+		_, authURL, _ := c.Start("mock", "")
+		code, state := authorize(t, authURL)
+		if _, _, err := c.Finish(ctx, "mock", state, code); err == nil {
+			t.Fatal("Finish accepted an ID token with a foreign nonce")
+		}
+	})
+
+	t.Run("CreatesAndLinksAccount", func(t *testing.T) {
+		svc := user.NewService(user.NewRepository(), nil, nil, nil)
+		p := user.ExternalProfile{Issuer: "https://idp.test", Subject: "s1", Email: "Bia@Example.com", EmailVerified: true}
+
+		u, err := svc.LoginExternal(p, "provider")
+		if err != nil {
+			t.Fatalf("LoginExternal: %v", err)
+		}
+		if u.Role != user.RoleProvider || u.Email != "bia@example.com" || !u.EmailVerified || u.Name != "bia" {
+			t.Errorf("unexpected user %+v", u)
+		}
+		again, err := svc.LoginExternal(p, "customer")
+		if err != nil || again.ID != u.ID {
+			t.Fatalf("second LoginExternal = %v, %v; want user %s", again, err, u.ID)
+		}
+		if _, err := svc.Authenticate("bia@example.com", "", ""); !errors.Is(err, user.ErrUnauthorized) {
+			t.Errorf("password login without password = %v, want ErrUnauthorized", err)
+		}
+	})
+
+	t.Run("UnverifiedEmailDoesNotLink", func(t *testing.T) {
+		svc := user.NewService(user.NewRepository(), nil, nil, nil)
+		if _, err := svc.Register("Ana", "ana@example.com", "secret123", "customer"); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		p := user.ExternalProfile{Issuer: "https://idp.test", Subject: "s2", Email: "ana@example.com"}
+		if _, err := svc.LoginExternal(p, ""); !errors.Is(err, user.ErrEmailTaken) {
+			t.Fatalf("LoginExternal = %v, want ErrEmailTaken", err)
+		}
+
+		p.EmailVerified = true
+		u, err := svc.LoginExternal(p, "")
+		if err != nil {
+			t.Fatalf("LoginExternal: %v", err)
+		}
+		ids, _ := svc.Identities(u.ID)
+		if len(ids) != 1 || ids[0].Subject != "s2" {
+			t.Errorf("identities = %+v, want the s2 link", ids)
+		}
+		// the password was set by whoever registered the unverified email
+		if _, err := svc.Authenticate("ana@example.com", "secret123", ""); !errors.Is(err, user.ErrUnauthorized) {
+			t.Errorf("password login after linking = %v, want ErrUnauthorized", err)
+		}
+	})
+}
+
+// authorize follows authURL like a browser that is already signed in at the
+// IdP and returns the code and state handed to the redirect URL.
+func authorize(t *testing.T, authURL string) (code, state string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize redirect: %v", err)
+	}
+	return loc.Query().Get("code"), loc.Query().Get("state")
+}
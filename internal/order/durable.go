@@ -0,0 +1,51 @@
+package order
+
+import (
+	"sort"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/durable"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
+)
+
+// durableRepo keeps the in-memory repository authoritative for reads and
+// writes every mutation ahead to the log, so the state can be rebuilt after
+// a restart.
+type durableRepo struct {
+	*memoryRepo
+	log *durable.Repo[Order]
+}
+
+func NewDurableRepository(w *wal.Log) (Repository, error) {
+	m := NewRepository().(*memoryRepo)
+	l, err := durable.NewRepo(w, "orders", m, m.all, durable.Checks[Order]{
+		Update: func(o *Order) error {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+			return m.updateErr(o)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &durableRepo{memoryRepo: m, log: l}, nil
+}
+
+func (r *durableRepo) Create(o *Order) error { return r.log.Create(o) }
+func (r *durableRepo) Update(o *Order) error { return r.log.Update(o) }
+
+func (r *memoryRepo) all() []Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Order, 0, len(r.byID))
+	for _, o := range r.byID {
+		out = append(out, *o)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
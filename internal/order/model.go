@@ -3,6 +3,8 @@ package order
 import (
 	"errors"
 	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/ports"
 )
 
 type Status string
@@ -16,10 +18,11 @@ const (
 )
 
 var (
-	ErrNotFound      = errors.New("order not found")
-	ErrForbidden     = errors.New("forbidden")
-	ErrInvalidFields = errors.New("invalid fields")
-	ErrInvalidState  = errors.New("invalid state transition")
+	ErrNotFound       = errors.New("order not found")
+	ErrForbidden      = errors.New("forbidden")
+	ErrInvalidFields  = errors.New("invalid fields")
+	ErrInvalidState   = errors.New("invalid state transition")
+	ErrInvalidAddress = errors.New("address is not in the customer's address book")
 )
 
 type HistoryEntry struct {
@@ -31,13 +34,16 @@ type HistoryEntry struct {
 }
 
 type Order struct {
-	ID          string         `json:"id"`
-	PostingID   string         `json:"postingId"`
-	ClientID    string         `json:"clientId"`
-	ProviderID  string         `json:"providerId"`
-	ScheduledAt *time.Time     `json:"scheduledAt,omitempty"`
-	Status      Status         `json:"status"`
-	History     []HistoryEntry `json:"history"`
+	ID          string     `json:"id"`
+	PostingID   string     `json:"postingId"`
+	ClientID    string     `json:"clientId"`
+	ProviderID  string     `json:"providerId"`
+	ScheduledAt *time.Time `json:"scheduledAt,omitempty"`
+	// Address is a copy of the customer's saved address at request time,
+	// so later edits to the address book do not move past orders.
+	Address *ports.Address `json:"address,omitempty"`
+	Status  Status         `json:"status"`
+	History []HistoryEntry `json:"history"`
 
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
@@ -0,0 +1,210 @@
+// Package ordertest provides a conformance suite for order.Repository
+// implementations.
+package ordertest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/order"
+	"github.com/Gab-Mello/service-finder/internal/ports"
+)
+
+// TestRepository runs the behavior every order.Repository must share with the
+// in-memory implementation. newRepo must return an empty repository on each
+// call.
+func TestRepository(t *testing.T, newRepo func() order.Repository) {
+	t.Helper()
+
+	t.Run("CreateAndFind", func(t *testing.T) {
+		r := newRepo()
+		o := sample("o1", "client1", "prov1")
+		if err := r.Create(o); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		got, err := r.ByID("o1")
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		assertEqual(t, got, o)
+	})
+
+	t.Run("CreateWithAddress", func(t *testing.T) {
+		r := newRepo()
+		o := sample("o1", "client1", "prov1")
+		o.Address = &ports.Address{
+			ID: "a1", Label: "Casa", Street: "Rua das Flores", Number: "10", District: "Boa Viagem",
+			City: "Recife", PostalCode: "51020-000", Notes: "portão azul",
+		}
+		mustCreate(t, r, o)
+		got, err := r.ByID("o1")
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		assertEqual(t, got, o)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo()
+		if _, err := r.ByID("missing"); !errors.Is(err, order.ErrNotFound) {
+			t.Fatalf("ByID: got %v, want ErrNotFound", err)
+		}
+		if err := r.Update(sample("missing", "client1", "prov1")); !errors.Is(err, order.ErrNotFound) {
+			t.Fatalf("Update: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("UpdateKeepsHistory", func(t *testing.T) {
+		r := newRepo()
+		o := sample("o1", "client1", "prov1")
+		mustCreate(t, r, o)
+
+		scheduled := o.CreatedAt.Add(48 * time.Hour)
+		o.ScheduledAt = &scheduled
+		o.Status = order.StatusAccepted
+		o.UpdatedAt = o.CreatedAt.Add(time.Hour)
+		o.History = append(o.History, order.HistoryEntry{
+			At: o.UpdatedAt, By: "prov1", From: order.StatusPending, To: order.StatusAccepted, Note: "aceito",
+		})
+		if err := r.Update(o); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := r.ByID("o1")
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		assertEqual(t, got, o)
+	})
+
+	t.Run("CopyOnRead", func(t *testing.T) {
+		r := newRepo()
+		o := sample("o1", "client1", "prov1")
+		mustCreate(t, r, o)
+		o.Status = order.StatusCanceled
+
+		got, _ := r.ByID("o1")
+		if got.Status != order.StatusPending {
+			t.Fatalf("Create kept a reference to the caller's value: %s", got.Status)
+		}
+		got.Status = order.StatusCompleted
+		got.History = append(got.History, order.HistoryEntry{To: order.StatusCompleted})
+
+		list, _ := r.ListMine("client1")
+		list[0].Status = order.StatusCompleted
+
+		again, _ := r.ByID("o1")
+		if again.Status != order.StatusPending || len(again.History) != 1 {
+			t.Fatalf("repository returned a reference to stored state: %+v", again)
+		}
+	})
+
+	t.Run("ListMine", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("o1", "client1", "prov1"))
+		mustCreate(t, r, sample("o2", "client2", "prov1"))
+		mustCreate(t, r, sample("o3", "client1", "prov2"))
+
+		assertIDs(t, r, "client1", "o1", "o3")
+		assertIDs(t, r, "prov1", "o1", "o2")
+		assertIDs(t, r, "client2", "o2")
+		assertIDs(t, r, "nobody")
+	})
+
+	t.Run("UpdateMovesParties", func(t *testing.T) {
+		r := newRepo()
+		o := sample("o1", "client1", "prov1")
+		mustCreate(t, r, o)
+		mustCreate(t, r, sample("o2", "client1", "prov1"))
+
+		o.ClientID = "client2"
+		if err := r.Update(o); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		assertIDs(t, r, "client1", "o2")
+		assertIDs(t, r, "client2", "o1")
+		assertIDs(t, r, "prov1", "o1", "o2")
+	})
+
+	t.Run("ListMineSameClientAndProvider", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("o1", "u1", "u1"))
+		assertIDs(t, r, "u1", "o1")
+	})
+}
+
+func sample(id, clientID, providerID string) *order.Order {
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	return &order.Order{
+		ID:         id,
+		PostingID:  "posting-" + id,
+		ClientID:   clientID,
+		ProviderID: providerID,
+		Status:     order.StatusPending,
+		History: []order.HistoryEntry{{
+			At: at, By: clientID, To: order.StatusPending, Note: "pedido criado",
+		}},
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+}
+
+func mustCreate(t *testing.T, r order.Repository, o *order.Order) {
+	t.Helper()
+	if err := r.Create(o); err != nil {
+		t.Fatalf("Create %s: %v", o.ID, err)
+	}
+}
+
+// assertIDs checks ListMine returns exactly want, in creation order.
+func assertIDs(t *testing.T, r order.Repository, userID string, want ...string) {
+	t.Helper()
+	list, err := r.ListMine(userID)
+	if err != nil {
+		t.Fatalf("ListMine %s: %v", userID, err)
+	}
+	if list == nil {
+		t.Fatalf("ListMine %s: got nil, want non-nil slice", userID)
+	}
+	got := make([]string, 0, len(list))
+	for _, o := range list {
+		got = append(got, o.ID)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ListMine %s: got %v, want %v", userID, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ListMine %s: got %v, want %v", userID, got, want)
+		}
+	}
+}
+
+func assertEqual(t *testing.T, got, want *order.Order) {
+	t.Helper()
+	if got.ID != want.ID || got.PostingID != want.PostingID || got.ClientID != want.ClientID ||
+		got.ProviderID != want.ProviderID || got.Status != want.Status ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	switch {
+	case got.ScheduledAt == nil && want.ScheduledAt == nil:
+	case got.ScheduledAt == nil || want.ScheduledAt == nil || !got.ScheduledAt.Equal(*want.ScheduledAt):
+		t.Fatalf("scheduledAt: got %v, want %v", got.ScheduledAt, want.ScheduledAt)
+	}
+	switch {
+	case got.Address == nil && want.Address == nil:
+	case got.Address == nil || want.Address == nil || *got.Address != *want.Address:
+		t.Fatalf("address: got %+v, want %+v", got.Address, want.Address)
+	}
+	if len(got.History) != len(want.History) {
+		t.Fatalf("history: got %+v, want %+v", got.History, want.History)
+	}
+	for i := range want.History {
+		g, w := got.History[i], want.History[i]
+		if !g.At.Equal(w.At) || g.By != w.By || g.From != w.From || g.To != w.To || g.Note != w.Note {
+			t.Fatalf("history[%d]: got %+v, want %+v", i, g, w)
+		}
+	}
+}
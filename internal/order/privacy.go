@@ -0,0 +1,43 @@
+package order
+
+import "slices"
+
+// ExportUser returns the orders a user placed or served, with history.
+func (s *Service) ExportUser(userID string) (any, error) {
+	return s.repo.ListMine(userID)
+}
+
+// AnonymizeUser replaces userID by alias in every order of a user whose
+// account is being erased. Open orders are canceled so the other party is
+// not left waiting, and the customer's address is dropped.
+func (s *Service) AnonymizeUser(userID, alias string) error {
+	list, err := s.repo.ListMine(userID)
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		o := &list[i]
+		o.History = slices.Clone(o.History)
+		switch o.Status {
+		case StatusPending, StatusAccepted, StatusInProgress:
+			s.transition(o, userID, StatusCanceled, "cancelado: conta removida")
+		}
+
+		if o.ClientID == userID {
+			o.ClientID = alias
+			o.Address = nil
+		}
+		if o.ProviderID == userID {
+			o.ProviderID = alias
+		}
+		for j := range o.History {
+			if o.History[j].By == userID {
+				o.History[j].By = alias
+			}
+		}
+		if err := s.repo.Update(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
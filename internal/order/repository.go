@@ -1,6 +1,9 @@
 package order
 
-import "sync"
+import (
+	"slices"
+	"sync"
+)
 
 type Repository interface {
 	Create(o *Order) error
@@ -52,14 +55,42 @@ func (r *memoryRepo) Update(o *Order) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.byID[o.ID]; !ok {
-		return ErrNotFound
+	if err := r.updateErr(o); err != nil {
+		return err
+	}
+	old := r.byID[o.ID]
+	// move the order between users when a party is replaced
+	before, after := parties(old), parties(o)
+	for _, u := range before {
+		if !slices.Contains(after, u) {
+			r.byUser[u] = slices.DeleteFunc(r.byUser[u], func(id string) bool { return id == o.ID })
+		}
+	}
+	for _, u := range after {
+		if !slices.Contains(before, u) {
+			r.byUser[u] = append(r.byUser[u], o.ID)
+		}
 	}
 	c := *o
 	r.byID[o.ID] = &c
 	return nil
 }
 
+// updateErr returns the error Update fails with for o; r.mu must be held.
+func (r *memoryRepo) updateErr(o *Order) error {
+	if _, ok := r.byID[o.ID]; !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func parties(o *Order) []string {
+	if o.ClientID == o.ProviderID {
+		return []string{o.ClientID}
+	}
+	return []string{o.ClientID, o.ProviderID}
+}
+
 func (r *memoryRepo) ListMine(userID string) ([]Order, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
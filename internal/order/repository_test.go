@@ -0,0 +1,18 @@
+package order_test
+
+import (
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/order"
+	"github.com/Gab-Mello/service-finder/internal/order/ordertest"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal/waltest"
+)
+
+func TestRepository(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		ordertest.TestRepository(t, order.NewRepository)
+	})
+	t.Run("Durable", func(t *testing.T) {
+		ordertest.TestRepository(t, waltest.Repository(t, "orders", order.NewDurableRepository))
+	})
+}
@@ -1,8 +1,12 @@
 package order
 
 import (
+	"errors"
+	"sort"
 	"time"
 
+	"github.com/Gab-Mello/service-finder/internal/pagination"
+	"github.com/Gab-Mello/service-finder/internal/ports"
 	"github.com/google/uuid"
 )
 
@@ -15,10 +19,11 @@ type noopNotifier struct{}
 func (noopNotifier) OrderStatusChanged(o *Order) {}
 
 type Service struct {
-	repo     Repository
-	now      func() time.Time
-	idgen    func() string
-	notifier Notifier
+	repo      Repository
+	now       func() time.Time
+	idgen     func() string
+	notifier  Notifier
+	addresses ports.AddressBook
 }
 
 func NewService(r Repository, now func() time.Time, idgen func() string, n Notifier) *Service {
@@ -34,7 +39,15 @@ func NewService(r Repository, now func() time.Time, idgen func() string, n Notif
 	return &Service{repo: r, now: now, idgen: idgen, notifier: n}
 }
 
-func (s *Service) Request(clientID, postingID, providerID string) (*Order, error) {
+// SetAddressBook lets customers attach one of their saved addresses to an
+// order.
+func (s *Service) SetAddressBook(b ports.AddressBook) {
+	s.addresses = b
+}
+
+// Request creates a pending order. addressID is optional; when given it must
+// name an address saved by the client.
+func (s *Service) Request(clientID, postingID, providerID, addressID string) (*Order, error) {
 	if clientID == "" || postingID == "" || providerID == "" {
 		return nil, ErrInvalidFields
 	}
@@ -42,12 +55,28 @@ func (s *Service) Request(clientID, postingID, providerID string) (*Order, error
 		return nil, ErrInvalidFields
 	}
 
+	var address *ports.Address
+	if addressID != "" {
+		if s.addresses == nil {
+			return nil, ErrInvalidAddress
+		}
+		a, err := s.addresses.Address(clientID, addressID)
+		if errors.Is(err, ports.ErrAddressNotFound) {
+			return nil, ErrInvalidAddress
+		}
+		if err != nil {
+			return nil, err
+		}
+		address = &a
+	}
+
 	now := s.now()
 	o := &Order{
 		ID:         s.idgen(),
 		PostingID:  postingID,
 		ClientID:   clientID,
 		ProviderID: providerID,
+		Address:    address,
 		Status:     StatusPending,
 		History: []HistoryEntry{{
 			At: now, By: clientID, From: "", To: StatusPending, Note: "pedido criado",
@@ -137,8 +166,19 @@ func (s *Service) GetForUser(userID, orderID string) (*Order, error) {
 	return o, nil
 }
 
-func (s *Service) ListMine(userID string) ([]Order, error) {
-	return s.repo.ListMine(userID)
+// ListMine returns a page of the orders the user placed or received,
+// newest first.
+func (s *Service) ListMine(userID string, page pagination.Params) (pagination.Page[Order], error) {
+	list, err := s.repo.ListMine(userID)
+	if err != nil {
+		return pagination.Page[Order]{}, err
+	}
+	sort.Slice(list, func(i, j int) bool { return pagination.NewestFirst(createdKey(list[i]), createdKey(list[j])) })
+	return pagination.Slice(list, page, "orders:mine", createdKey, pagination.NewestFirst)
+}
+
+func createdKey(o Order) pagination.Created {
+	return pagination.Created{At: o.CreatedAt, ID: o.ID}
 }
 
 func (s *Service) transition(o *Order, by string, to Status, note string) {
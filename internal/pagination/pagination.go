@@ -0,0 +1,139 @@
+// Package pagination pages through sorted listings with opaque cursors.
+//
+// A cursor holds the sort key of the last item of a page rather than its
+// position, so the next page starts right after that item even when items
+// are added or removed in between. An item whose own sort key changes
+// between two pages can still be skipped or seen twice, since it moves to
+// the other side of the cursor; listings whose keys all shift together,
+// such as search relevance, are paged on a best-effort basis.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 50
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+// Params select a page: the cursor returned with the previous page, empty
+// for the first one, and how many items to return. Limits outside 1 to
+// MaxLimit are clamped.
+type Params struct {
+	Cursor string
+	Limit  int
+}
+
+// Page is one page of a listing. Total counts the whole listing; NextCursor
+// is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// FromQuery reads the cursor and limit query parameters. A limit that is
+// not a positive integer gets ErrInvalidLimit; cursors are checked when
+// used.
+func FromQuery(q url.Values) (Params, error) {
+	p := Params{Cursor: q.Get("cursor")}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return Params{}, ErrInvalidLimit
+		}
+		p.Limit = n
+	}
+	return p, nil
+}
+
+func (p Params) limit() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultLimit
+	case p.Limit > MaxLimit:
+		return MaxLimit
+	}
+	return p.Limit
+}
+
+// Created is the key of listings ordered by creation time.
+type Created struct {
+	At time.Time `json:"t"`
+	ID string    `json:"id"`
+}
+
+// NewestFirst orders Created keys from the most recent, breaking ties on
+// the ID.
+func NewestFirst(a, b Created) bool {
+	if !a.At.Equal(b.At) {
+		return a.At.After(b.At)
+	}
+	return a.ID > b.ID
+}
+
+type cursor[K any] struct {
+	Listing string `json:"l"`
+	After   *K     `json:"a"`
+}
+
+// Slice returns the page of sorted selected by p. sorted must be ordered by
+// less on the keys returned by key, and keys must be unique, typically by
+// ending in the ID. listing names the listing and its order: cursors issued
+// for another listing are rejected with ErrInvalidCursor, as are malformed
+// ones.
+func Slice[T, K any](sorted []T, p Params, listing string, key func(T) K, less func(a, b K) bool) (Page[T], error) {
+	start := 0
+	if p.Cursor != "" {
+		after, err := decode[K](p.Cursor, listing)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		start = sort.Search(len(sorted), func(i int) bool { return less(after, key(sorted[i])) })
+	}
+
+	end := min(start+p.limit(), len(sorted))
+	page := Page[T]{Items: make([]T, 0, end-start), Total: len(sorted)}
+	page.Items = append(page.Items, sorted[start:end]...)
+	if end < len(sorted) {
+		next, err := encode(listing, key(sorted[end-1]))
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+func encode[K any](listing string, after K) (string, error) {
+	b, err := json.Marshal(cursor[K]{Listing: listing, After: &after})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decode[K any](s, listing string) (K, error) {
+	var zero K
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return zero, ErrInvalidCursor
+	}
+	var c cursor[K]
+	if err := json.Unmarshal(b, &c); err != nil || c.After == nil || c.Listing != listing {
+		return zero, ErrInvalidCursor
+	}
+	return *c.After, nil
+}
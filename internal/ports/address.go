@@ -0,0 +1,23 @@
+package ports
+
+import "errors"
+
+// ErrAddressNotFound is returned by an AddressBook for an address the user
+// has not saved.
+var ErrAddressNotFound = errors.New("address not found")
+
+// Address is a place where a service is performed.
+type Address struct {
+	ID         string `json:"id"`
+	Label      string `json:"label"`
+	Street     string `json:"street"`
+	Number     string `json:"number"`
+	District   string `json:"district"`
+	City       string `json:"city"`
+	PostalCode string `json:"postalCode"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+type AddressBook interface {
+	Address(userID, addressID string) (Address, error)
+}
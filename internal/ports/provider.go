@@ -2,4 +2,6 @@ package ports
 
 type ProviderDirectory interface {
 	GetNameByID(providerID string) (string, error)
+	EmailVerified(providerID string) (bool, error)
+	Suspended(providerID string) (bool, error)
 }
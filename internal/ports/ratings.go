@@ -1,5 +1,15 @@
 package ports
 
+// Rating is the average of a provider's review stars and how many reviews
+// it is based on.
+type Rating struct {
+	Avg   float64
+	Count int
+}
+
 type Ratings interface {
 	AvgForProvider(providerID string) (avg float64, count int)
+	// RatingsForProviders looks up several providers at once. Providers
+	// without reviews are left out.
+	RatingsForProviders(providerIDs []string) map[string]Rating
 }
@@ -0,0 +1,56 @@
+package posting
+
+import (
+	"sort"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/durable"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
+)
+
+// durableRepo keeps the in-memory repository authoritative for reads and
+// writes every mutation ahead to the log, so the state can be rebuilt after
+// a restart.
+type durableRepo struct {
+	*memoryRepo
+	log *durable.Repo[Posting]
+}
+
+func NewDurableRepository(w *wal.Log) (Repository, error) {
+	m := NewRepository().(*memoryRepo)
+	l, err := durable.NewRepo(w, "postings", m, m.all, durable.Checks[Posting]{
+		Create: func(p *Posting) error {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+			return m.createErr(p)
+		},
+		Update: func(p *Posting) error {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+			return m.updateErr(p)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &durableRepo{memoryRepo: m, log: l}, nil
+}
+
+func (r *durableRepo) Create(p *Posting) error { return r.log.Create(p) }
+func (r *durableRepo) Update(p *Posting) error { return r.log.Update(p) }
+
+func (r *memoryRepo) all() []Posting {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Posting, 0, len(r.byID))
+	for _, p := range r.byID {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
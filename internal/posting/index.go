@@ -0,0 +1,179 @@
+package posting
+
+import "sync"
+
+// set is a set of posting IDs.
+type set map[string]struct{}
+
+// index keeps the public postings searchable without rescanning the
+// repository: an inverted index from search terms to postings, and exact
+// indexes on the normalized category, city and district. The service
+// updates it after each successful write.
+type index struct {
+	mu         sync.RWMutex
+	docs       map[string]*entry
+	terms      map[string]set
+	byCategory map[string]set
+	byCity     map[string]set
+	byDistrict map[string]set
+	totalLen   float64
+}
+
+type entry struct {
+	posting Posting
+	doc     document
+}
+
+// filter holds the exact-match conditions of a search. Text fields must be
+// normalized.
+type filter struct {
+	category, city, district string
+	priceMin, priceMax       int64
+}
+
+type hit struct {
+	posting Posting
+	score   float64
+}
+
+func newIndex(public []Posting) *index {
+	ix := &index{
+		docs:       make(map[string]*entry),
+		terms:      make(map[string]set),
+		byCategory: make(map[string]set),
+		byCity:     make(map[string]set),
+		byDistrict: make(map[string]set),
+	}
+	for i := range public {
+		ix.add(public[i])
+	}
+	return ix
+}
+
+// put indexes p, replacing its previous version. Archived postings are
+// removed.
+func (ix *index) put(p Posting) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(p.ID)
+	if !p.Archived {
+		ix.add(p)
+	}
+}
+
+func (ix *index) add(p Posting) {
+	e := &entry{posting: p, doc: analyze(&p)}
+	ix.docs[p.ID] = e
+	ix.totalLen += e.doc.length
+	for t := range e.doc.freq {
+		addTo(ix.terms, t, p.ID)
+	}
+	addTo(ix.byCategory, normalize(p.Category), p.ID)
+	addTo(ix.byCity, normalize(p.City), p.ID)
+	addTo(ix.byDistrict, normalize(p.District), p.ID)
+}
+
+func (ix *index) remove(id string) {
+	e, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	delete(ix.docs, id)
+	ix.totalLen -= e.doc.length
+	for t := range e.doc.freq {
+		removeFrom(ix.terms, t, id)
+	}
+	removeFrom(ix.byCategory, normalize(e.posting.Category), id)
+	removeFrom(ix.byCity, normalize(e.posting.City), id)
+	removeFrom(ix.byDistrict, normalize(e.posting.District), id)
+}
+
+// search returns the postings holding every term and passing f, scored
+// against the whole index. Only the smallest of the matching ID sets is
+// walked.
+func (ix *index) search(terms []string, f filter) []hit {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	var required []set
+	for _, t := range terms {
+		required = append(required, ix.terms[t])
+	}
+	for _, c := range []struct {
+		want string
+		by   map[string]set
+	}{{f.category, ix.byCategory}, {f.city, ix.byCity}, {f.district, ix.byDistrict}} {
+		if c.want != "" {
+			required = append(required, c.by[c.want])
+		}
+	}
+
+	stats := ix.corpus(terms)
+	match := func(e *entry) (hit, bool) {
+		p := e.posting
+		if f.priceMin > 0 && p.Price < f.priceMin || f.priceMax > 0 && p.Price > f.priceMax {
+			return hit{}, false
+		}
+		return hit{posting: p, score: stats.score(e.doc, terms)}, true
+	}
+
+	if len(required) == 0 {
+		out := make([]hit, 0, len(ix.docs))
+		for _, e := range ix.docs {
+			if h, ok := match(e); ok {
+				out = append(out, h)
+			}
+		}
+		return out
+	}
+
+	smallest := required[0]
+	for _, s := range required[1:] {
+		if len(s) < len(smallest) {
+			smallest = s
+		}
+	}
+	out := make([]hit, 0, len(smallest))
+next:
+	for id := range smallest {
+		for _, s := range required {
+			if _, ok := s[id]; !ok {
+				continue next
+			}
+		}
+		if h, ok := match(ix.docs[id]); ok {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// corpus returns the statistics BM25 needs for terms. Callers hold ix.mu.
+func (ix *index) corpus(terms []string) corpus {
+	c := corpus{docs: len(ix.docs), df: make(map[string]int, len(terms))}
+	if c.docs > 0 {
+		c.avgLen = ix.totalLen / float64(c.docs)
+	}
+	for _, t := range terms {
+		c.df[t] = len(ix.terms[t])
+	}
+	return c
+}
+
+func addTo(m map[string]set, key, id string) {
+	s, ok := m[key]
+	if !ok {
+		s = make(set)
+		m[key] = s
+	}
+	s[id] = struct{}{}
+}
+
+func removeFrom(m map[string]set, key, id string) {
+	s := m[key]
+	delete(s, id)
+	if len(s) == 0 {
+		delete(m, key)
+	}
+}
@@ -0,0 +1,204 @@
+// Package postingtest provides a conformance suite for posting.Repository
+// implementations.
+package postingtest
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/posting"
+)
+
+// TestRepository runs the behavior every posting.Repository must share with
+// the in-memory implementation. newRepo must return an empty repository on
+// each call.
+func TestRepository(t *testing.T, newRepo func() posting.Repository) {
+	t.Helper()
+
+	t.Run("CreateAndFind", func(t *testing.T) {
+		r := newRepo()
+		p := sample("p1", "prov1")
+		if err := r.Create(p); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		got, err := r.ByID("p1")
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		assertEqual(t, got, p)
+	})
+
+	t.Run("CreateDuplicateID", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("p1", "prov1"))
+		dup := sample("p1", "prov2")
+		if err := r.Create(dup); !errors.Is(err, posting.ErrInvalidFields) {
+			t.Fatalf("Create: got %v, want ErrInvalidFields", err)
+		}
+		if list, _ := r.ListByProvider("prov2"); len(list) != 0 {
+			t.Fatalf("rejected posting was indexed: %+v", list)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo()
+		if _, err := r.ByID("missing"); !errors.Is(err, posting.ErrNotFound) {
+			t.Fatalf("ByID: got %v, want ErrNotFound", err)
+		}
+		if err := r.Update(sample("missing", "prov1")); !errors.Is(err, posting.ErrNotFound) {
+			t.Fatalf("Update: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		r := newRepo()
+		p := sample("p1", "prov1")
+		mustCreate(t, r, p)
+
+		p.Title = "Pintura residencial"
+		p.Price = 25000
+		p.Archived = true
+		p.UpdatedAt = p.UpdatedAt.Add(time.Hour)
+		if err := r.Update(p); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, err := r.ByID("p1")
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		assertEqual(t, got, p)
+	})
+
+	t.Run("ListByProvider", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("p1", "prov1"))
+		mustCreate(t, r, sample("p2", "prov2"))
+		archived := sample("p3", "prov1")
+		archived.Archived = true
+		mustCreate(t, r, archived)
+
+		list, err := r.ListByProvider("prov1")
+		if err != nil {
+			t.Fatalf("ListByProvider: %v", err)
+		}
+		assertIDs(t, list, "p1", "p3")
+
+		list, err = r.ListByProvider("nobody")
+		if err != nil {
+			t.Fatalf("ListByProvider: %v", err)
+		}
+		if list == nil || len(list) != 0 {
+			t.Fatalf("ListByProvider unknown: got %#v, want empty non-nil slice", list)
+		}
+	})
+
+	t.Run("UpdateMovesProvider", func(t *testing.T) {
+		r := newRepo()
+		p := sample("p1", "prov1")
+		mustCreate(t, r, p)
+		mustCreate(t, r, sample("p2", "prov1"))
+
+		p.ProviderID = "prov2"
+		if err := r.Update(p); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		list, _ := r.ListByProvider("prov1")
+		assertIDs(t, list, "p2")
+		list, _ = r.ListByProvider("prov2")
+		assertIDs(t, list, "p1")
+	})
+
+	t.Run("ListPublicSkipsArchived", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("p1", "prov1"))
+		mustCreate(t, r, sample("p2", "prov2"))
+		p3 := sample("p3", "prov1")
+		mustCreate(t, r, p3)
+
+		p3.Archived = true
+		if err := r.Update(p3); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		list, err := r.ListPublic()
+		if err != nil {
+			t.Fatalf("ListPublic: %v", err)
+		}
+		assertIDs(t, list, "p1", "p2")
+	})
+
+	t.Run("ReturnsCopies", func(t *testing.T) {
+		r := newRepo()
+		p := sample("p1", "prov1")
+		mustCreate(t, r, p)
+		p.Title = "mutated after create"
+
+		got, _ := r.ByID("p1")
+		if got.Title != "Pintura" {
+			t.Fatalf("Create kept a reference to the caller's value: %q", got.Title)
+		}
+		got.Title = "mutated after read"
+
+		list, _ := r.ListPublic()
+		list[0].Title = "mutated list"
+
+		again, _ := r.ByID("p1")
+		if again.Title != "Pintura" {
+			t.Fatalf("repository returned a reference to stored state: %q", again.Title)
+		}
+	})
+}
+
+func sample(id, providerID string) *posting.Posting {
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	return &posting.Posting{
+		ID:           id,
+		ProviderID:   providerID,
+		ProviderName: "Carlos",
+		Title:        "Pintura",
+		Description:  "Pintura de paredes internas",
+		Price:        15000,
+		Category:     "pintura",
+		City:         "Recife",
+		District:     "Boa Viagem",
+		CreatedAt:    at,
+		UpdatedAt:    at,
+	}
+}
+
+func mustCreate(t *testing.T, r posting.Repository, p *posting.Posting) {
+	t.Helper()
+	if err := r.Create(p); err != nil {
+		t.Fatalf("Create %s: %v", p.ID, err)
+	}
+}
+
+func assertIDs(t *testing.T, list []posting.Posting, want ...string) {
+	t.Helper()
+	got := make([]string, 0, len(list))
+	for _, p := range list {
+		got = append(got, p.ID)
+	}
+	sort.Strings(got)
+	if len(got) != len(want) {
+		t.Fatalf("got IDs %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got IDs %v, want %v", got, want)
+		}
+	}
+}
+
+func assertEqual(t *testing.T, got, want *posting.Posting) {
+	t.Helper()
+	if got.ID != want.ID || got.ProviderID != want.ProviderID || got.ProviderName != want.ProviderName ||
+		got.Title != want.Title || got.Description != want.Description || got.Price != want.Price ||
+		got.Category != want.Category || got.City != want.City || got.District != want.District ||
+		got.Archived != want.Archived ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,32 @@
+package posting
+
+// ExportUser returns the postings of a provider, archived ones included.
+func (s *Service) ExportUser(userID string) (any, error) {
+	return s.repo.ListByProvider(userID)
+}
+
+// AnonymizeUser archives the postings of a provider whose account is being
+// erased and strips the provider's name and free text from them. Title,
+// price and category stay, as orders still point at the posting.
+func (s *Service) AnonymizeUser(userID, alias string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	list, err := s.repo.ListByProvider(userID)
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		p := &list[i]
+		p.ProviderID = alias
+		p.ProviderName = ""
+		p.Description = ""
+		p.Archived = true
+		p.UpdatedAt = s.now()
+		if err := s.repo.Update(p); err != nil {
+			return err
+		}
+		s.reindex(p)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package posting
+
+import "github.com/Gab-Mello/service-finder/internal/ports"
+
+// The rating sort ranks providers by a Bayesian average: their reviews are
+// weighed together with ratingPriorWeight imaginary reviews of ratingPrior
+// stars, so a single 5-star review does not outrank two hundred 4.8s and
+// averages only count in full once enough reviews back them.
+const (
+	ratingPrior       = 3.0
+	ratingPriorWeight = 5.0
+)
+
+// confidentRating is the Bayesian average of r. Providers without reviews
+// get ratingPrior.
+func confidentRating(r ports.Rating) float64 {
+	n := float64(r.Count)
+	return (ratingPrior*ratingPriorWeight + r.Avg*n) / (ratingPriorWeight + n)
+}
+
+// providerRatings fetches the ratings of providerIDs, which may repeat, in
+// one call.
+func (s *Service) providerRatings(providerIDs []string) map[string]ports.Rating {
+	if s.ratings == nil || len(providerIDs) == 0 {
+		return map[string]ports.Rating{}
+	}
+	seen := make(map[string]bool)
+	var unique []string
+	for _, id := range providerIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return s.ratings.RatingsForProviders(unique)
+}
+
+func withRatings(list []Posting, ratings map[string]ports.Rating) {
+	for i := range list {
+		r := ratings[list[i].ProviderID]
+		list[i].ProviderAvg = r.Avg
+		list[i].ProviderReviews = r.Count
+	}
+}
@@ -1,6 +1,9 @@
 package posting
 
-import "sync"
+import (
+	"slices"
+	"sync"
+)
 
 type Repository interface {
 	Create(*Posting) error
@@ -27,8 +30,8 @@ func (r *memoryRepo) Create(p *Posting) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.byID[p.ID]; exists {
-		return ErrInvalidFields // ID already exists
+	if err := r.createErr(p); err != nil {
+		return err
 	}
 
 	r.byID[p.ID] = *p
@@ -40,10 +43,31 @@ func (r *memoryRepo) Update(p *Posting) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if err := r.updateErr(p); err != nil {
+		return err
+	}
+	old := r.byID[p.ID]
+	if old.ProviderID != p.ProviderID {
+		r.byProvider[old.ProviderID] = slices.DeleteFunc(r.byProvider[old.ProviderID], func(id string) bool { return id == p.ID })
+		r.byProvider[p.ProviderID] = append(r.byProvider[p.ProviderID], p.ID)
+	}
+	r.byID[p.ID] = *p
+	return nil
+}
+
+// createErr returns the error Create fails with for p; r.mu must be held.
+func (r *memoryRepo) createErr(p *Posting) error {
+	if _, exists := r.byID[p.ID]; exists {
+		return ErrInvalidFields // ID already exists
+	}
+	return nil
+}
+
+// updateErr returns the error Update fails with for p; r.mu must be held.
+func (r *memoryRepo) updateErr(p *Posting) error {
 	if _, ok := r.byID[p.ID]; !ok {
 		return ErrNotFound
 	}
-	r.byID[p.ID] = *p
 	return nil
 }
 
@@ -0,0 +1,18 @@
+package posting_test
+
+import (
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/posting"
+	"github.com/Gab-Mello/service-finder/internal/posting/postingtest"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal/waltest"
+)
+
+func TestRepository(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		postingtest.TestRepository(t, posting.NewRepository)
+	})
+	t.Run("Durable", func(t *testing.T) {
+		postingtest.TestRepository(t, waltest.Repository(t, "postings", posting.NewDurableRepository))
+	})
+}
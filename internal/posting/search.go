@@ -0,0 +1,116 @@
+package posting
+
+import (
+	"math"
+	"time"
+)
+
+// BM25 parameters. Title terms count titleBoost times, so a match in the
+// title outweighs the same match in the description.
+const (
+	bm25K1     = 1.2
+	bm25B      = 0.75
+	titleBoost = 3
+)
+
+// document is a posting as search terms.
+type document struct {
+	freq   map[string]float64 // weighted term frequency
+	length float64            // weighted number of terms
+}
+
+func analyze(p *Posting) document {
+	d := document{freq: make(map[string]float64)}
+	for _, t := range tokenize(p.Title) {
+		d.freq[t] += titleBoost
+		d.length += titleBoost
+	}
+	for _, t := range tokenize(p.Description) {
+		d.freq[t]++
+		d.length++
+	}
+	return d
+}
+
+// corpus holds the collection statistics BM25 weighs terms with.
+type corpus struct {
+	docs   int
+	avgLen float64
+	df     map[string]int
+}
+
+// score is the BM25 relevance of d for terms.
+func (c corpus) score(d document, terms []string) float64 {
+	if c.avgLen == 0 {
+		return 0
+	}
+	var s float64
+	for _, t := range terms {
+		tf := d.freq[t]
+		if tf == 0 {
+			continue
+		}
+		df := float64(c.df[t])
+		idf := math.Log(1 + (float64(c.docs)-df+0.5)/(df+0.5))
+		s += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*d.length/c.avgLen))
+	}
+	return s
+}
+
+// queryTerms tokenizes a search query, dropping repeated terms.
+func queryTerms(q string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, t := range tokenize(q) {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// searchKey is the position of a result in the search order and what its
+// page cursor holds. Price and Rating are only set when sorting by them.
+//
+// Score is relative to the catalog: BM25 weighs terms by how many postings
+// contain them, so creating, editing or archiving any posting can move
+// every score. A relevance cursor resumes after the score it saw and may
+// skip or repeat results when the catalog changed in between; price and
+// rating cursors only do so for the postings whose price or rating changed.
+type searchKey struct {
+	Price     int64     `json:"p,omitempty"`
+	Rating    float64   `json:"r,omitempty"`
+	Reviews   int       `json:"n,omitempty"`
+	Score     float64   `json:"s,omitempty"`
+	UpdatedAt time.Time `json:"u"`
+	ID        string    `json:"id"`
+}
+
+// searchOrder compares keys by the requested sort, then by relevance,
+// recency and finally the ID, so every result has a single position.
+// Ratings tie on the number of reviews before relevance.
+func searchOrder(sortKey, order string) func(a, b searchKey) bool {
+	return func(a, b searchKey) bool {
+		switch sortKey {
+		case "price":
+			if a.Price != b.Price {
+				return (a.Price < b.Price) == (order == "asc")
+			}
+		case "rating":
+			if a.Rating != b.Rating {
+				return (a.Rating > b.Rating) == (order == "desc")
+			}
+			if a.Reviews != b.Reviews {
+				return a.Reviews > b.Reviews
+			}
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.UpdatedAt.Equal(b.UpdatedAt) {
+			return a.UpdatedAt.After(b.UpdatedAt)
+		}
+		return a.ID < b.ID
+	}
+}
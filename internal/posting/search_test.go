@@ -0,0 +1,211 @@
+package posting
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	catalogServices  = []string{"Eletricista", "Pintor", "Encanador", "Diarista", "Jardineiro", "Marceneiro", "Pedreiro", "Técnico de ar-condicionado"}
+	catalogDetails   = []string{"residencial", "comercial", "com garantia", "atendimento rápido", "orçamento grátis", "fins de semana"}
+	catalogCities    = []string{"São Paulo", "Recife", "Curitiba", "Belo Horizonte", "Salvador"}
+	catalogDistricts = []string{"Centro", "Boa Vista", "Jardim América", "Vila Nova", "Santo Antônio", "Alto da Sé"}
+)
+
+var searchCases = []struct {
+	name   string
+	query  string
+	filter filter
+}{
+	{"Query", "eletricista com garantia", filter{}},
+	{"Filters", "", filter{category: normalize("Pintor"), city: normalize("Recife"), district: normalize("Boa Vista")}},
+	{"QueryAndFilters", "jardineiro", filter{city: normalize("curitiba")}},
+	{"Price", "", filter{priceMin: 100, priceMax: 150}},
+	{"All", "", filter{}},
+}
+
+// newCatalog returns a service over n public postings spread over a few
+// services, cities and districts.
+func newCatalog(tb testing.TB, n int) *Service {
+	tb.Helper()
+	svc := NewService(NewRepository(), catalogDirectory{}, nil, nil, nil)
+	for i := range n {
+		service := catalogServices[i%len(catalogServices)]
+		detail := catalogDetails[(i/len(catalogServices))%len(catalogDetails)]
+		_, err := svc.Create(fmt.Sprintf("prov%d", i%200),
+			fmt.Sprintf("%s %s %d", service, detail, i),
+			fmt.Sprintf("Serviço de %s %s. Atendo toda a região com pontualidade e capricho.", service, detail),
+			int64(50+i%500), service, catalogCities[i%len(catalogCities)], catalogDistricts[i%len(catalogDistricts)])
+		if err != nil {
+			tb.Fatalf("Create: %v", err)
+		}
+	}
+	return svc
+}
+
+type catalogDirectory struct{}
+
+func (catalogDirectory) GetNameByID(id string) (string, error) { return "Provider " + id, nil }
+func (catalogDirectory) EmailVerified(string) (bool, error)    { return true, nil }
+func (catalogDirectory) Suspended(string) (bool, error)        { return false, nil }
+
+// scan is the search the index replaced: it lists every public posting and
+// analyzes all of them on each query.
+func scan(repo Repository, terms []string, f filter) []hit {
+	all, err := repo.ListPublic()
+	if err != nil {
+		panic(err)
+	}
+	docs := make([]document, len(all))
+	stats := corpus{docs: len(all), df: make(map[string]int)}
+	var total float64
+	for i := range all {
+		docs[i] = analyze(&all[i])
+		total += docs[i].length
+		for t := range docs[i].freq {
+			stats.df[t]++
+		}
+	}
+	if stats.docs > 0 {
+		stats.avgLen = total / float64(stats.docs)
+	}
+
+	out := make([]hit, 0)
+next:
+	for i, p := range all {
+		for _, t := range terms {
+			if docs[i].freq[t] == 0 {
+				continue next
+			}
+		}
+		if f.category != "" && normalize(p.Category) != f.category ||
+			f.city != "" && normalize(p.City) != f.city ||
+			f.district != "" && normalize(p.District) != f.district ||
+			f.priceMin > 0 && p.Price < f.priceMin || f.priceMax > 0 && p.Price > f.priceMax {
+			continue
+		}
+		out = append(out, hit{posting: p, score: stats.score(docs[i], terms)})
+	}
+	return out
+}
+
+func TestIndexMatchesScan(t *testing.T) {
+	svc := newCatalog(t, 500)
+	ix, err := svc.searchIndex()
+	if err != nil {
+		t.Fatalf("searchIndex: %v", err)
+	}
+
+	// keep the index busy with edits and archives after it was built
+	list, _ := svc.repo.ListPublic()
+	for i, p := range list[:60] {
+		if i%2 == 0 {
+			_, err = svc.Update(p.ProviderID, p.ID, map[string]any{"title": "Pintor com garantia", "city": "Recife"})
+		} else {
+			err = svc.Archive(p.ProviderID, p.ID)
+		}
+		if err != nil {
+			t.Fatalf("edit %s: %v", p.ID, err)
+		}
+	}
+
+	for _, c := range searchCases {
+		t.Run(c.name, func(t *testing.T) {
+			terms := queryTerms(c.query)
+			got, want := ix.search(terms, c.filter), scan(svc.repo, terms, c.filter)
+			if len(want) == 0 {
+				t.Fatal("scan found nothing; the case tests nothing")
+			}
+			if len(got) != len(want) {
+				t.Fatalf("index found %d postings, scan %d", len(got), len(want))
+			}
+			byID := func(h []hit) { sort.Slice(h, func(i, j int) bool { return h[i].posting.ID < h[j].posting.ID }) }
+			byID(got)
+			byID(want)
+			for i := range want {
+				if got[i].posting.ID != want[i].posting.ID {
+					t.Fatalf("index found %s, scan %s", got[i].posting.ID, want[i].posting.ID)
+				}
+				if math.Abs(got[i].score-want[i].score) > 1e-9 {
+					t.Fatalf("%s: index score %v, scan %v", want[i].posting.ID, got[i].score, want[i].score)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	for _, n := range []int{1_000, 10_000, 50_000} {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			svc := newCatalog(b, n)
+			ix, err := svc.searchIndex()
+			if err != nil {
+				b.Fatalf("searchIndex: %v", err)
+			}
+			for _, c := range searchCases {
+				terms := queryTerms(c.query)
+				b.Run(c.name+"/Index", func(b *testing.B) {
+					b.ReportAllocs()
+					for b.Loop() {
+						ix.search(terms, c.filter)
+					}
+				})
+				b.Run(c.name+"/Scan", func(b *testing.B) {
+					b.ReportAllocs()
+					for b.Loop() {
+						scan(svc.repo, terms, c.filter)
+					}
+				})
+			}
+		})
+	}
+}
+
+// slowRepo widens the gap between a repository write and the reindex that
+// follows it.
+type slowRepo struct{ Repository }
+
+func (r slowRepo) Update(p *Posting) error {
+	err := r.Repository.Update(p)
+	time.Sleep(time.Duration(rand.IntN(100)) * time.Microsecond)
+	return err
+}
+
+func TestConcurrentUpdatesKeepIndexCurrent(t *testing.T) {
+	svc := newCatalog(t, 20)
+	svc.repo = slowRepo{svc.repo}
+	ix, err := svc.searchIndex()
+	if err != nil {
+		t.Fatalf("searchIndex: %v", err)
+	}
+	list, _ := svc.repo.ListPublic()
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 50 {
+				p := list[(g+i)%len(list)]
+				title := fmt.Sprintf("Pintor %d %d", g, i)
+				if _, err := svc.Update(p.ProviderID, p.ID, map[string]any{"title": title}); err != nil {
+					t.Errorf("Update: %v", err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	for _, p := range list {
+		stored, err := svc.repo.ByID(p.ID)
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		if indexed := ix.docs[p.ID].posting.Title; indexed != stored.Title {
+			t.Errorf("%s: indexed %q, stored %q", p.ID, indexed, stored.Title)
+		}
+	}
+}
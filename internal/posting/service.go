@@ -5,8 +5,10 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Gab-Mello/service-finder/internal/pagination"
 	"github.com/Gab-Mello/service-finder/internal/ports"
 	"github.com/google/uuid"
 )
@@ -25,6 +27,14 @@ type Service struct {
 	ratings   ports.Ratings
 	now       func() time.Time
 	idgen     func() string
+
+	requireVerified bool
+
+	// writeMu serializes writes with their reindex, so that the index ends
+	// up with the last version written rather than the last one indexed.
+	writeMu sync.Mutex
+	indexMu sync.Mutex
+	index   *index
 }
 
 func NewService(r Repository, providers ports.ProviderDirectory, now func() time.Time, idgen func() string, ratings ports.Ratings) *Service {
@@ -43,6 +53,12 @@ func NewService(r Repository, providers ports.ProviderDirectory, now func() time
 	}
 }
 
+// RequireVerifiedProviders makes Create reject providers that have not
+// confirmed their email address.
+func (s *Service) RequireVerifiedProviders(require bool) {
+	s.requireVerified = require
+}
+
 func (s *Service) Create(providerID, title, desc string, price int64, category, city, district string) (*Posting, error) {
 	title = strings.TrimSpace(title)
 	desc = strings.TrimSpace(desc)
@@ -66,6 +82,15 @@ func (s *Service) Create(providerID, title, desc string, price int64, category,
 		log.Printf("failed to get provider name for ID %s: %v", providerID, err)
 		return nil, ErrInvalidFields
 	}
+	if s.requireVerified {
+		verified, err := s.providers.EmailVerified(providerID)
+		if err != nil {
+			return nil, err
+		}
+		if !verified {
+			return nil, ErrUnverified
+		}
+	}
 
 	p := &Posting{
 		ID:           s.idgen(),
@@ -80,13 +105,19 @@ func (s *Service) Create(providerID, title, desc string, price int64, category,
 		CreatedAt:    s.now(),
 		UpdatedAt:    s.now(),
 	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 	if err := s.repo.Create(p); err != nil {
 		return nil, err
 	}
+	s.reindex(p)
 	return p, nil
 }
 
 func (s *Service) Update(providerID, id string, patch map[string]any) (*Posting, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	p, err := s.repo.ByID(id)
 	if err != nil {
 		return nil, err
@@ -141,10 +172,14 @@ func (s *Service) Update(providerID, id string, patch map[string]any) (*Posting,
 	if err := s.repo.Update(p); err != nil {
 		return nil, err
 	}
+	s.reindex(p)
 	return p, nil
 }
 
 func (s *Service) Archive(providerID, id string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	p, err := s.repo.ByID(id)
 	if err != nil {
 		return err
@@ -154,7 +189,33 @@ func (s *Service) Archive(providerID, id string) error {
 	}
 	p.Archived = true
 	p.UpdatedAt = s.now()
-	return s.repo.Update(p)
+	if err := s.repo.Update(p); err != nil {
+		return err
+	}
+	s.reindex(p)
+	return nil
+}
+
+// RenameProvider updates the provider name copied into each posting.
+func (s *Service) RenameProvider(providerID, name string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	list, err := s.repo.ListByProvider(providerID)
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		if list[i].ProviderName == name {
+			continue
+		}
+		list[i].ProviderName = name
+		if err := s.repo.Update(&list[i]); err != nil {
+			return err
+		}
+		s.reindex(&list[i])
+	}
+	return nil
 }
 
 func (s *Service) GetPublic(id string) (*Posting, error) {
@@ -162,21 +223,32 @@ func (s *Service) GetPublic(id string) (*Posting, error) {
 	if err != nil {
 		return nil, err
 	}
-	if p.Archived {
+	if p.Archived || s.providerSuspended(p.ProviderID, nil) {
 		return nil, ErrNotFound
 	}
 	s.enrich(p)
 	return p, nil
 }
 
-func (s *Service) ListMine(providerID string) ([]Posting, error) {
+// ListMine returns a page of the provider's postings, archived ones
+// included, newest first.
+func (s *Service) ListMine(providerID string, page pagination.Params) (pagination.Page[Posting], error) {
 	list, err := s.repo.ListByProvider(providerID)
 	if err != nil {
 		log.Printf("failed to list postings for provider %s: %v", providerID, err)
-		return nil, err
+		return pagination.Page[Posting]{}, err
 	}
-	s.enrichMany(list)
-	return list, nil
+	sort.Slice(list, func(i, j int) bool { return pagination.NewestFirst(createdKey(list[i]), createdKey(list[j])) })
+	out, err := pagination.Slice(list, page, "postings:mine", createdKey, pagination.NewestFirst)
+	if err != nil {
+		return pagination.Page[Posting]{}, err
+	}
+	s.enrichMany(out.Items)
+	return out, nil
+}
+
+func createdKey(p Posting) pagination.Created {
+	return pagination.Created{At: p.CreatedAt, ID: p.ID}
 }
 
 func (s *Service) ListPublic() ([]Posting, error) {
@@ -185,6 +257,7 @@ func (s *Service) ListPublic() ([]Posting, error) {
 		log.Printf("failed to list public postings: %v", err)
 		return nil, err
 	}
+	list = s.withoutSuspended(list)
 	s.enrichMany(list)
 	return list, nil
 }
@@ -197,15 +270,16 @@ type SearchParams struct {
 	RatingMin float64
 	Sort      string
 	Order     string
-	Limit     int
-	Offset    int
+	Page      pagination.Params
 }
 
-func (s *Service) Search(p SearchParams) ([]Posting, int) {
-	all, err := s.repo.ListPublic()
+// Search returns a page of the public postings matching p. Malformed
+// cursors and cursors of a different sort get pagination.ErrInvalidCursor.
+func (s *Service) Search(p SearchParams) (pagination.Page[Posting], error) {
+	ix, err := s.searchIndex()
 	if err != nil {
 		log.Printf("failed to list public postings for search: %v", err)
-		return []Posting{}, -1
+		return pagination.Page[Posting]{}, err
 	}
 
 	if u, err := url.QueryUnescape(p.Query); err == nil {
@@ -221,126 +295,167 @@ func (s *Service) Search(p SearchParams) ([]Posting, int) {
 		p.District = u
 	}
 
-	norm := func(x string) string {
-		x = strings.TrimSpace(strings.ToLower(x))
-		return strings.Join(strings.Fields(x), " ")
-	}
-
-	q := norm(p.Query)
-	wantCat := norm(p.Category)
-	wantCity := norm(p.City)
-	wantDist := norm(p.District)
+	found := ix.search(queryTerms(p.Query), filter{
+		category: normalize(p.Category),
+		city:     normalize(p.City),
+		district: normalize(p.District),
+		priceMin: p.PriceMin,
+		priceMax: p.PriceMax,
+	})
 
-	filtered := make([]Posting, 0, len(all))
-	for _, it := range all {
-		titleDesc := norm(it.Title + " " + it.Description)
-		gotCat := norm(it.Category)
-		gotCity := norm(it.City)
-		gotDist := norm(it.District)
-
-		if q != "" && !strings.Contains(titleDesc, q) {
-			continue
-		}
-		if wantCat != "" && gotCat != wantCat {
-			continue
+	sortKey := strings.ToLower(p.Sort)
+	order := strings.ToLower(p.Order)
+	switch sortKey {
+	case "price":
+		if order != "desc" {
+			order = "asc"
 		}
-		if wantCity != "" && gotCity != wantCity {
-			continue
+	case "rating":
+		if order != "asc" {
+			order = "desc"
 		}
-		if wantDist != "" && gotDist != wantDist {
-			continue
+	default:
+		sortKey, order = "relevance", ""
+	}
+
+	suspended := make(map[string]bool)
+	hits := found[:0]
+	for _, h := range found {
+		if !s.providerSuspended(h.posting.ProviderID, suspended) {
+			hits = append(hits, h)
 		}
-		if p.PriceMin > 0 && it.Price < p.PriceMin {
-			continue
+	}
+
+	// ratings are fetched up front only to filter or sort on them;
+	// otherwise just the returned page is looked up
+	var ratings map[string]ports.Rating
+	if p.RatingMin > 0 || sortKey == "rating" {
+		ids := make([]string, len(hits))
+		for i := range hits {
+			ids[i] = hits[i].posting.ProviderID
 		}
-		if p.PriceMax > 0 && it.Price > p.PriceMax {
-			continue
+		ratings = s.providerRatings(ids)
+	}
+	if p.RatingMin > 0 {
+		kept := hits[:0]
+		for _, h := range hits {
+			if ratings[h.posting.ProviderID].Avg >= p.RatingMin {
+				kept = append(kept, h)
+			}
 		}
-
-		filtered = append(filtered, it)
+		hits = kept
 	}
 
-	sortKey := strings.ToLower(p.Sort)
-	order := strings.ToLower(p.Order)
-	if sortKey == "" {
-		sortKey = "relevance"
+	type result struct {
+		posting *Posting
+		key     searchKey
 	}
-	less := func(i, j int) bool {
+	results := make([]result, len(hits))
+	for i := range hits {
+		h := &hits[i]
+		k := searchKey{Score: h.score, UpdatedAt: h.posting.UpdatedAt, ID: h.posting.ID}
 		switch sortKey {
 		case "price":
-			if order == "desc" {
-				return filtered[i].Price > filtered[j].Price
-			}
-			return filtered[i].Price < filtered[j].Price
+			k.Price = h.posting.Price
 		case "rating":
-			fallthrough
-		default:
-			qi := 0
-			qj := 0
-			if q != "" && strings.Contains(norm(filtered[i].Title), q) {
-				qi = 1
-			}
-			if q != "" && strings.Contains(norm(filtered[j].Title), q) {
-				qj = 1
-			}
-			if qi != qj {
-				return qi > qj
-			}
-
-			return filtered[i].UpdatedAt.After(filtered[j].UpdatedAt)
+			r := ratings[h.posting.ProviderID]
+			k.Rating, k.Reviews = confidentRating(r), r.Count
 		}
+		results[i] = result{posting: &h.posting, key: k}
 	}
-	sort.SliceStable(filtered, less)
+	less := searchOrder(sortKey, order)
+	sort.Slice(results, func(i, j int) bool { return less(results[i].key, results[j].key) })
 
-	limit := p.Limit
-	if limit <= 0 || limit > 50 {
-		limit = 20
+	page, err := pagination.Slice(results, p.Page, "postings:"+sortKey+":"+order,
+		func(r result) searchKey { return r.key }, less)
+	if err != nil {
+		return pagination.Page[Posting]{}, err
 	}
-	offset := p.Offset
-	if offset < 0 {
-		offset = 0
+	out := pagination.Page[Posting]{Items: make([]Posting, len(page.Items)), Total: page.Total, NextCursor: page.NextCursor}
+	for i := range page.Items {
+		out.Items[i] = *page.Items[i].posting
 	}
-	if offset >= len(filtered) {
-		return []Posting{}, -1
+	if ratings != nil {
+		withRatings(out.Items, ratings)
+	} else {
+		s.enrichMany(out.Items)
 	}
-	end := offset + limit
-	if end > len(filtered) {
-		end = len(filtered)
+	return out, nil
+}
+
+// searchIndex returns the search index, building it from the repository
+// on first use.
+func (s *Service) searchIndex() (*index, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	if s.index == nil {
+		public, err := s.repo.ListPublic()
+		if err != nil {
+			return nil, err
+		}
+		s.index = newIndex(public)
 	}
-	next := -1
-	if end < len(filtered) {
-		next = end
+	return s.index, nil
+}
+
+// reindex brings the search index up to date with a posting just written
+// to the repository. An index not built yet will read it from there.
+// Callers hold s.writeMu from the write until reindex returns.
+func (s *Service) reindex(p *Posting) {
+	s.indexMu.Lock()
+	ix := s.index
+	s.indexMu.Unlock()
+
+	if ix != nil {
+		ix.put(*p)
 	}
+}
 
-	page := filtered[offset:end]
-	s.enrichMany(page)
+// withoutSuspended drops the postings of suspended providers. They come
+// back on their own once the suspension ends.
+func (s *Service) withoutSuspended(list []Posting) []Posting {
+	cache := make(map[string]bool)
+	out := list[:0]
+	for _, p := range list {
+		if !s.providerSuspended(p.ProviderID, cache) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
 
-	return page, next
+// providerSuspended looks the provider up, through cache when given. A
+// failed lookup keeps the posting visible.
+func (s *Service) providerSuspended(providerID string, cache map[string]bool) bool {
+	if s.providers == nil {
+		return false
+	}
+	if suspended, ok := cache[providerID]; ok {
+		return suspended
+	}
+	suspended, err := s.providers.Suspended(providerID)
+	if err != nil {
+		log.Printf("failed to check suspension of provider %s: %v", providerID, err)
+		return false
+	}
+	if cache != nil {
+		cache[providerID] = suspended
+	}
+	return suspended
 }
 
 func (s *Service) enrich(p *Posting) {
 	if s.ratings == nil || p == nil {
 		return
 	}
-	if avg, _ := s.ratings.AvgForProvider(p.ProviderID); avg > 0 {
-		p.ProviderAvg = avg
-	}
+	p.ProviderAvg, p.ProviderReviews = s.ratings.AvgForProvider(p.ProviderID)
 }
 
 func (s *Service) enrichMany(list []Posting) {
-	if s.ratings == nil {
-		return
-	}
-	avgCache := make(map[string]float64)
+	ids := make([]string, len(list))
 	for i := range list {
-		pid := list[i].ProviderID
-		avg, cached := avgCache[pid]
-		if !cached {
-			avg, _ = s.ratings.AvgForProvider(pid)
-			avgCache[pid] = avg
-		}
-		if avg > 0 {
-			list[i].ProviderAvg = avg
-		}
+		ids[i] = list[i].ProviderID
 	}
+	withRatings(list, s.providerRatings(ids))
 }
@@ -0,0 +1,119 @@
+package posting
+
+import (
+	"strings"
+	"unicode"
+)
+
+// accents maps the accented letters used in Portuguese to their base
+// letter.
+var accents = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
+
+var stopWords = makeSet(
+	"a", "o", "as", "os", "um", "uma", "uns", "umas",
+	"de", "da", "do", "das", "dos", "em", "na", "no", "nas", "nos",
+	"ao", "aos", "pela", "pelo", "pelas", "pelos", "num", "numa",
+	"e", "ou", "que", "se", "com", "sem", "por", "para", "pra", "pro",
+	"mais", "muito", "ja", "nao", "sim", "eu", "voce", "ele", "ela",
+	"meu", "minha", "seu", "sua", "este", "esta", "esse", "essa", "isso",
+	"ser", "tem", "ter",
+)
+
+// suffixes are stripped by stem, longest first, when enough of the word
+// remains.
+var suffixes = []string{
+	"amento", "imento", "adora", "ador", "edora", "edor",
+	"mente", "acao", "icao", "ismo", "ista",
+	"eira", "eiro", "dora", "ura", "dor", "ora", "or",
+}
+
+const minStemLen = 3
+
+// fold lowercases s and strips Portuguese accents, so "Eletricísta" and
+// "eletricista" compare equal.
+func fold(s string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if base, ok := accents[r]; ok {
+			return base
+		}
+		return r
+	}, s)
+}
+
+// normalize folds s and collapses its whitespace, for exact comparisons
+// such as the category and location filters.
+func normalize(s string) string {
+	return strings.Join(strings.Fields(fold(s)), " ")
+}
+
+// tokenize splits text into search terms: folded words without stop
+// words, each reduced to its stem.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		if _, stop := stopWords[w]; stop {
+			continue
+		}
+		out = append(out, stem(w))
+	}
+	return out
+}
+
+// stem is a light Portuguese stemmer: it drops the plural, one common
+// derivational suffix and a final vowel, so "pintor", "pintora" and
+// "pintura" share a stem. Short words are left alone.
+func stem(w string) string {
+	if len(w) <= minStemLen {
+		return w
+	}
+	w = singular(w)
+	for _, suf := range suffixes {
+		if strings.HasSuffix(w, suf) && len(w)-len(suf) >= minStemLen {
+			w = w[:len(w)-len(suf)]
+			break
+		}
+	}
+	if n := len(w); n > minStemLen && strings.ContainsRune("aeo", rune(w[n-1])) {
+		w = w[:n-1]
+	}
+	return w
+}
+
+func singular(w string) string {
+	switch {
+	case strings.HasSuffix(w, "oes"), strings.HasSuffix(w, "aes"):
+		return w[:len(w)-3] + "ao"
+	case strings.HasSuffix(w, "ais"):
+		return w[:len(w)-3] + "al"
+	case strings.HasSuffix(w, "eis"):
+		return w[:len(w)-3] + "el"
+	case strings.HasSuffix(w, "ois"):
+		return w[:len(w)-3] + "ol"
+	case strings.HasSuffix(w, "res"), strings.HasSuffix(w, "zes"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ns"):
+		return w[:len(w)-2] + "m"
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "is") && !strings.HasSuffix(w, "us"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func makeSet(words ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,56 @@
+package review
+
+import (
+	"sort"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/durable"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
+)
+
+// durableRepo keeps the in-memory repository authoritative for reads and
+// writes every mutation ahead to the log, so the state can be rebuilt after
+// a restart.
+type durableRepo struct {
+	*memoryRepo
+	log *durable.Repo[Review]
+}
+
+func NewDurableRepository(w *wal.Log) (Repository, error) {
+	m := NewRepository().(*memoryRepo)
+	l, err := durable.NewRepo(w, "reviews", m, m.all, durable.Checks[Review]{
+		Create: func(rv *Review) error {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+			return m.createErr(rv)
+		},
+		Update: func(rv *Review) error {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+			return m.updateErr(rv)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &durableRepo{memoryRepo: m, log: l}, nil
+}
+
+func (r *durableRepo) Create(rv *Review) error { return r.log.Create(rv) }
+func (r *durableRepo) Update(rv *Review) error { return r.log.Update(rv) }
+
+func (r *memoryRepo) all() []Review {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Review, 0, len(r.byOrder))
+	for _, rv := range r.byOrder {
+		out = append(out, *rv)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].OrderID < out[j].OrderID
+	})
+	return out
+}
@@ -23,3 +23,9 @@ type Review struct {
 	CreatedAt  time.Time `json:"createdAt"`
 	UpdatedAt  time.Time `json:"updatedAt"`
 }
+
+// Summary aggregates the reviews of one provider.
+type Summary struct {
+	Count int
+	Stars int // sum of the stars of all reviews
+}
@@ -0,0 +1,52 @@
+package review
+
+import "errors"
+
+// ExportUser returns the reviews written by or about a user.
+func (s *Service) ExportUser(userID string) (any, error) {
+	return s.byUser(userID)
+}
+
+// AnonymizeUser replaces userID by alias in the reviews of a user whose
+// account is being erased. Comments the user wrote are removed but stars
+// are kept, so provider averages do not change. Reviews are found through
+// the user's orders, so this must run before the orders are anonymized.
+func (s *Service) AnonymizeUser(userID, alias string) error {
+	list, err := s.byUser(userID)
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		rv := &list[i]
+		if rv.ClientID == userID {
+			rv.ClientID = alias
+			rv.Comment = ""
+		}
+		if rv.ProviderID == userID {
+			rv.ProviderID = alias
+		}
+		if err := s.repo.Update(rv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) byUser(userID string) ([]Review, error) {
+	orders, err := s.orders.ListMine(userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Review, 0)
+	for _, o := range orders {
+		rv, err := s.repo.ByOrderID(o.ID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rv)
+	}
+	return out, nil
+}
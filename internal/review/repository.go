@@ -1,12 +1,18 @@
 package review
 
-import "sync"
+import (
+	"slices"
+	"sync"
+)
 
 type Repository interface {
 	Create(r *Review) error
 	ByOrderID(orderID string) (*Review, error)
 	Update(r *Review) error
 	ListByProvider(providerID string) ([]Review, error)
+	// Summaries returns the review summary of each of providerIDs that has
+	// been reviewed; providers without reviews are left out.
+	Summaries(providerIDs []string) (map[string]Summary, error)
 }
 
 type memoryRepo struct {
@@ -26,8 +32,8 @@ func (r *memoryRepo) Create(rv *Review) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.byOrder[rv.OrderID]; ok {
-		return ErrAlreadyExists
+	if err := r.createErr(rv); err != nil {
+		return err
 	}
 	c := *rv
 	r.byOrder[rv.OrderID] = &c
@@ -35,6 +41,22 @@ func (r *memoryRepo) Create(rv *Review) error {
 	return nil
 }
 
+// createErr returns the error Create fails with for rv; r.mu must be held.
+func (r *memoryRepo) createErr(rv *Review) error {
+	if _, ok := r.byOrder[rv.OrderID]; ok {
+		return ErrAlreadyExists
+	}
+	return nil
+}
+
+// updateErr returns the error Update fails with for rv; r.mu must be held.
+func (r *memoryRepo) updateErr(rv *Review) error {
+	if _, ok := r.byOrder[rv.OrderID]; !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (r *memoryRepo) ByOrderID(orderID string) (*Review, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -51,20 +73,17 @@ func (r *memoryRepo) Update(rv *Review) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	old, ok := r.byOrder[rv.OrderID]
-	if !ok {
-		return ErrNotFound
+	if err := r.updateErr(rv); err != nil {
+		return err
 	}
 
-	*old = *rv
-
-	arr := r.byProvider[rv.ProviderID]
-	for i := range arr {
-		if arr[i].OrderID == rv.OrderID {
-			*(arr[i]) = *rv
-			break
-		}
+	old := r.byOrder[rv.OrderID]
+	if old.ProviderID != rv.ProviderID {
+		r.byProvider[old.ProviderID] = slices.DeleteFunc(r.byProvider[old.ProviderID], func(v *Review) bool { return v == old })
+		r.byProvider[rv.ProviderID] = append(r.byProvider[rv.ProviderID], old)
 	}
+	// byProvider shares the pointer stored in byOrder
+	*old = *rv
 	return nil
 }
 
@@ -79,3 +98,22 @@ func (r *memoryRepo) ListByProvider(providerID string) ([]Review, error) {
 	}
 	return out, nil
 }
+
+func (r *memoryRepo) Summaries(providerIDs []string) (map[string]Summary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[string]Summary, len(providerIDs))
+	for _, id := range providerIDs {
+		arr := r.byProvider[id]
+		if len(arr) == 0 {
+			continue
+		}
+		sum := Summary{Count: len(arr)}
+		for _, rv := range arr {
+			sum.Stars += rv.Stars
+		}
+		out[id] = sum
+	}
+	return out, nil
+}
@@ -0,0 +1,18 @@
+package review_test
+
+import (
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/review"
+	"github.com/Gab-Mello/service-finder/internal/review/reviewtest"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal/waltest"
+)
+
+func TestRepository(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		reviewtest.TestRepository(t, review.NewRepository)
+	})
+	t.Run("Durable", func(t *testing.T) {
+		reviewtest.TestRepository(t, waltest.Repository(t, "reviews", review.NewDurableRepository))
+	})
+}
@@ -0,0 +1,206 @@
+// Package reviewtest provides a conformance suite for review.Repository
+// implementations.
+package reviewtest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/review"
+)
+
+// TestRepository runs the behavior every review.Repository must share with
+// the in-memory implementation. newRepo must return an empty repository on
+// each call.
+func TestRepository(t *testing.T, newRepo func() review.Repository) {
+	t.Helper()
+
+	t.Run("CreateAndFind", func(t *testing.T) {
+		r := newRepo()
+		rv := sample("o1", "prov1")
+		if err := r.Create(rv); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		got, err := r.ByOrderID("o1")
+		if err != nil {
+			t.Fatalf("ByOrderID: %v", err)
+		}
+		assertEqual(t, got, rv)
+	})
+
+	t.Run("OneReviewPerOrder", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("o1", "prov1"))
+
+		dup := sample("o1", "prov1")
+		dup.Stars = 1
+		if err := r.Create(dup); !errors.Is(err, review.ErrAlreadyExists) {
+			t.Fatalf("Create: got %v, want ErrAlreadyExists", err)
+		}
+		got, _ := r.ByOrderID("o1")
+		if got.Stars != 5 {
+			t.Fatalf("rejected review overwrote the original: %+v", got)
+		}
+		if list, _ := r.ListByProvider("prov1"); len(list) != 1 {
+			t.Fatalf("rejected review was indexed: %+v", list)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo()
+		if _, err := r.ByOrderID("missing"); !errors.Is(err, review.ErrNotFound) {
+			t.Fatalf("ByOrderID: got %v, want ErrNotFound", err)
+		}
+		if err := r.Update(sample("missing", "prov1")); !errors.Is(err, review.ErrNotFound) {
+			t.Fatalf("Update: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("UpdateVisibleInProviderList", func(t *testing.T) {
+		r := newRepo()
+		rv := sample("o1", "prov1")
+		mustCreate(t, r, rv)
+		mustCreate(t, r, sample("o2", "prov1"))
+
+		rv.Stars = 2
+		rv.Comment = "atrasou"
+		rv.UpdatedAt = rv.UpdatedAt.Add(time.Hour)
+		if err := r.Update(rv); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, _ := r.ByOrderID("o1")
+		assertEqual(t, got, rv)
+
+		list, err := r.ListByProvider("prov1")
+		if err != nil {
+			t.Fatalf("ListByProvider: %v", err)
+		}
+		if len(list) != 2 {
+			t.Fatalf("ListByProvider: got %d reviews, want 2", len(list))
+		}
+		for _, it := range list {
+			if it.OrderID == "o1" {
+				assertEqual(t, &it, rv)
+			}
+		}
+	})
+
+	t.Run("UpdateMovesProvider", func(t *testing.T) {
+		r := newRepo()
+		rv := sample("o1", "prov1")
+		mustCreate(t, r, rv)
+		mustCreate(t, r, sample("o2", "prov1"))
+
+		rv.ProviderID = "prov2"
+		if err := r.Update(rv); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if list, _ := r.ListByProvider("prov1"); len(list) != 1 || list[0].OrderID != "o2" {
+			t.Fatalf("ListByProvider prov1: got %+v, want only o2", list)
+		}
+		if list, _ := r.ListByProvider("prov2"); len(list) != 1 || list[0].OrderID != "o1" {
+			t.Fatalf("ListByProvider prov2: got %+v, want only o1", list)
+		}
+	})
+
+	t.Run("ListByProvider", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("o1", "prov1"))
+		mustCreate(t, r, sample("o2", "prov2"))
+
+		list, err := r.ListByProvider("prov2")
+		if err != nil {
+			t.Fatalf("ListByProvider: %v", err)
+		}
+		if len(list) != 1 || list[0].OrderID != "o2" {
+			t.Fatalf("ListByProvider: got %+v", list)
+		}
+		list, _ = r.ListByProvider("nobody")
+		if list == nil || len(list) != 0 {
+			t.Fatalf("ListByProvider unknown: got %#v, want empty non-nil slice", list)
+		}
+	})
+
+	t.Run("Summaries", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("o1", "prov1"))
+		low := sample("o2", "prov1")
+		low.Stars = 2
+		mustCreate(t, r, low)
+		mustCreate(t, r, sample("o3", "prov2"))
+
+		got, err := r.Summaries([]string{"prov1", "prov2", "nobody"})
+		if err != nil {
+			t.Fatalf("Summaries: %v", err)
+		}
+		want := map[string]review.Summary{
+			"prov1": {Count: 2, Stars: 7},
+			"prov2": {Count: 1, Stars: 5},
+		}
+		if len(got) != len(want) || got["prov1"] != want["prov1"] || got["prov2"] != want["prov2"] {
+			t.Fatalf("Summaries: got %+v, want %+v", got, want)
+		}
+
+		low.Stars = 4
+		if err := r.Update(low); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if got, _ := r.Summaries([]string{"prov1"}); got["prov1"] != (review.Summary{Count: 2, Stars: 9}) {
+			t.Fatalf("Summaries after update: got %+v", got)
+		}
+		if got, _ := r.Summaries(nil); len(got) != 0 {
+			t.Fatalf("Summaries of no providers: got %+v", got)
+		}
+	})
+
+	t.Run("ReturnsCopies", func(t *testing.T) {
+		r := newRepo()
+		rv := sample("o1", "prov1")
+		mustCreate(t, r, rv)
+		rv.Stars = 1
+
+		got, _ := r.ByOrderID("o1")
+		if got.Stars != 5 {
+			t.Fatalf("Create kept a reference to the caller's value: %d", got.Stars)
+		}
+		got.Stars = 2
+		list, _ := r.ListByProvider("prov1")
+		list[0].Stars = 3
+
+		again, _ := r.ByOrderID("o1")
+		if again.Stars != 5 {
+			t.Fatalf("repository returned a reference to stored state: %d", again.Stars)
+		}
+	})
+}
+
+func sample(orderID, providerID string) *review.Review {
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	return &review.Review{
+		OrderID:    orderID,
+		ClientID:   "client1",
+		ProviderID: providerID,
+		Stars:      5,
+		Comment:    "ótimo serviço",
+		CreatedAt:  at,
+		UpdatedAt:  at,
+	}
+}
+
+func mustCreate(t *testing.T, r review.Repository, rv *review.Review) {
+	t.Helper()
+	if err := r.Create(rv); err != nil {
+		t.Fatalf("Create %s: %v", rv.OrderID, err)
+	}
+}
+
+func assertEqual(t *testing.T, got, want *review.Review) {
+	t.Helper()
+	if got.OrderID != want.OrderID || got.ClientID != want.ClientID || got.ProviderID != want.ProviderID ||
+		got.Stars != want.Stars || got.Comment != want.Comment ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
@@ -1,11 +1,16 @@
 package review
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/Gab-Mello/service-finder/internal/order"
+	"github.com/Gab-Mello/service-finder/internal/pagination"
+	"github.com/Gab-Mello/service-finder/internal/ports"
 )
 
 const maxCommentLen = 2000
@@ -106,18 +111,44 @@ func (s *Service) ByOrderID(orderID string) (*Review, error) {
 	return s.repo.ByOrderID(orderID)
 }
 
-func (s *Service) AvgForProvider(providerID string) (avg float64, count int) {
+// ListForProvider returns a page of the reviews a provider received, newest
+// first.
+func (s *Service) ListForProvider(providerID string, page pagination.Params) (pagination.Page[Review], error) {
 	list, err := s.repo.ListByProvider(providerID)
 	if err != nil {
-		log.Printf("error listing reviews for provider %s: %v", providerID, err)
-		return 0, 0
+		return pagination.Page[Review]{}, err
 	}
-	if len(list) == 0 {
+	sort.Slice(list, func(i, j int) bool { return pagination.NewestFirst(createdKey(list[i]), createdKey(list[j])) })
+	return pagination.Slice(list, page, "reviews:provider", createdKey, pagination.NewestFirst)
+}
+
+// createdKey identifies reviews by a digest of their order ID, unique as
+// there is one review per order. The listing is public, so its cursors must
+// not carry the order ID itself.
+func createdKey(rv Review) pagination.Created {
+	sum := sha256.Sum256([]byte(rv.OrderID))
+	return pagination.Created{At: rv.CreatedAt, ID: hex.EncodeToString(sum[:16])}
+}
+
+func (s *Service) AvgForProvider(providerID string) (avg float64, count int) {
+	r, ok := s.RatingsForProviders([]string{providerID})[providerID]
+	if !ok {
 		return 0, 0
 	}
-	var sum int
-	for _, r := range list {
-		sum += r.Stars
+	return r.Avg, r.Count
+}
+
+// RatingsForProviders aggregates the reviews of providerIDs in a single
+// repository call. On error it logs and reports no ratings.
+func (s *Service) RatingsForProviders(providerIDs []string) map[string]ports.Rating {
+	out := make(map[string]ports.Rating)
+	sums, err := s.repo.Summaries(providerIDs)
+	if err != nil {
+		log.Printf("error summarizing reviews of %d providers: %v", len(providerIDs), err)
+		return out
+	}
+	for id, sum := range sums {
+		out[id] = ports.Rating{Avg: float64(sum.Stars) / float64(sum.Count), Count: sum.Count}
 	}
-	return float64(sum) / float64(len(list)), len(list)
+	return out
 }
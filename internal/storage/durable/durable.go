@@ -0,0 +1,175 @@
+// Package durable keeps in-memory stores across restarts by writing each
+// mutation ahead to a wal.Log before making it.
+//
+// Log serves any store; Repo covers the repositories whose records are
+// created, updated and deleted whole.
+package durable
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
+)
+
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Log writes the mutations of an in-memory store of T ahead to a wal.Log
+// and compacts the log with snapshots of the whole store.
+type Log[T any] struct {
+	mu   sync.Mutex // serializes mutations with their log record and snapshots
+	wal  *wal.Log
+	name string
+	all  func() []T
+}
+
+// Open rebuilds a store from w: restore gets the latest snapshot and apply
+// every record logged after it. name is used in error messages and all
+// returns the whole store for the next snapshot.
+func Open[T any](w *wal.Log, name string, all func() []T, restore func([]T) error, apply func(op string, dec wal.Decoder) error) (*Log[T], error) {
+	err := w.Replay(
+		func(dec wal.Decoder) error {
+			var snap []T
+			if err := dec(&snap); err != nil {
+				return err
+			}
+			return restore(snap)
+		},
+		apply,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Log[T]{wal: w, name: name, all: all}, nil
+}
+
+// Do logs the mutation op with payload v and only then makes it with
+// apply, so the store never holds a change the log lost. check runs first,
+// under the same lock, and must return every error apply can fail with: a
+// record that cannot be applied would fail the next replay too. A nil
+// check means the mutation cannot fail.
+func (l *Log[T]) Do(op string, v any, check, apply func() error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if check != nil {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	if err := l.wal.Append(op, v); err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		return fmt.Errorf("%s: logged %s could not be applied: %w", l.name, op, err)
+	}
+	// the mutation is already durable in the log, so a failed compaction
+	// only postpones the next snapshot
+	if l.wal.SnapshotDue() {
+		if err := l.wal.Snapshot(l.all()); err != nil {
+			log.Printf("failed to snapshot %s: %v", l.name, err)
+		}
+	}
+	return nil
+}
+
+// Memory is an in-memory repository that a Repo makes durable.
+type Memory[T any] interface {
+	Create(v *T) error
+	Update(v *T) error
+}
+
+// Deleter is implemented by Memory repositories that delete records by
+// ID; Repo.Delete needs it.
+type Deleter interface {
+	Delete(id string) error
+}
+
+// Checks report the error a Memory mutation would fail with, without
+// making it. They are called with no other mutation in progress. A nil
+// check means the mutation cannot fail.
+type Checks[T any] struct {
+	Create func(v *T) error
+	Update func(v *T) error
+	Delete func(id string) error
+}
+
+// Repo is the durable form of a Memory repository. Reads go straight to
+// the memory repository; Repo only handles mutations.
+type Repo[T any] struct {
+	log    *Log[T]
+	mem    Memory[T]
+	checks Checks[T]
+}
+
+// NewRepo replays w into mem and returns the Repo that logs its further
+// mutations. all returns every record of mem in a stable order, the one
+// they are restored in.
+func NewRepo[T any](w *wal.Log, name string, mem Memory[T], all func() []T, checks Checks[T]) (*Repo[T], error) {
+	restore := func(snap []T) error {
+		for i := range snap {
+			if err := mem.Create(&snap[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	apply := func(op string, dec wal.Decoder) error {
+		if op == OpDelete {
+			d, ok := mem.(Deleter)
+			if !ok {
+				return fmt.Errorf("unknown operation %q", op)
+			}
+			var id string
+			if err := dec(&id); err != nil {
+				return err
+			}
+			return d.Delete(id)
+		}
+
+		var v T
+		if err := dec(&v); err != nil {
+			return err
+		}
+		switch op {
+		case OpCreate:
+			return mem.Create(&v)
+		case OpUpdate:
+			return mem.Update(&v)
+		default:
+			return fmt.Errorf("unknown operation %q", op)
+		}
+	}
+
+	l, err := Open(w, name, all, restore, apply)
+	if err != nil {
+		return nil, err
+	}
+	return &Repo[T]{log: l, mem: mem, checks: checks}, nil
+}
+
+func (r *Repo[T]) Create(v *T) error {
+	return r.log.Do(OpCreate, v, bind(r.checks.Create, v), func() error { return r.mem.Create(v) })
+}
+
+func (r *Repo[T]) Update(v *T) error {
+	return r.log.Do(OpUpdate, v, bind(r.checks.Update, v), func() error { return r.mem.Update(v) })
+}
+
+// Delete panics if the Memory repository is not a Deleter.
+func (r *Repo[T]) Delete(id string) error {
+	d := r.mem.(Deleter)
+	return r.log.Do(OpDelete, id, bind(r.checks.Delete, id), func() error { return d.Delete(id) })
+}
+
+func bind[V any](check func(V) error, v V) func() error {
+	if check == nil {
+		return nil
+	}
+	return func() error { return check(v) }
+}
@@ -0,0 +1,137 @@
+package durable_test
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/durable"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
+)
+
+var errExists = errors.New("exists")
+
+type item struct {
+	ID   string
+	Name string
+}
+
+type memory struct {
+	mu    sync.Mutex
+	items map[string]item
+}
+
+func (m *memory) Create(v *item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.items[v.ID]; ok {
+		return errExists
+	}
+	m.items[v.ID] = *v
+	return nil
+}
+
+func (m *memory) Update(v *item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[v.ID] = *v
+	return nil
+}
+
+func (m *memory) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
+
+func (m *memory) all() []item {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]item, 0, len(m.items))
+	for _, v := range m.items {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func open(t *testing.T, dir string) (*durable.Repo[item], *memory, *wal.Log) {
+	t.Helper()
+	w, err := wal.Open(dir, "items", wal.Options{SnapshotEvery: 3})
+	if err != nil {
+		t.Fatalf("wal.Open: %v", err)
+	}
+	m := &memory{items: make(map[string]item)}
+	r, err := durable.NewRepo(w, "items", m, m.all, durable.Checks[item]{
+		Create: func(v *item) error {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if _, ok := m.items[v.ID]; ok {
+				return errExists
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRepo: %v", err)
+	}
+	return r, m, w
+}
+
+func TestRepoReplay(t *testing.T) {
+	dir := t.TempDir()
+	r, _, w := open(t, dir)
+	// enough mutations to cross a snapshot
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if err := r.Create(&item{ID: id, Name: id}); err != nil {
+			t.Fatalf("Create %s: %v", id, err)
+		}
+	}
+	if err := r.Update(&item{ID: "b", Name: "bee"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := r.Delete("c"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := r.Create(&item{ID: "a"}); !errors.Is(err, errExists) {
+		t.Fatalf("Create duplicate = %v, want errExists", err)
+	}
+	w.Close()
+
+	_, m, w := open(t, dir)
+	defer w.Close()
+	want := []item{{"a", "a"}, {"b", "bee"}, {"d", "d"}}
+	got := m.all()
+	if len(got) != len(want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("replayed %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRepoFailedAppendLeavesMemory(t *testing.T) {
+	r, m, w := open(t, t.TempDir())
+	if err := r.Create(&item{ID: "a", Name: "a"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// appends to a closed log fail
+	w.Close()
+
+	if err := r.Create(&item{ID: "b"}); err == nil {
+		t.Fatal("Create succeeded on a closed log")
+	}
+	if err := r.Update(&item{ID: "a", Name: "changed"}); err == nil {
+		t.Fatal("Update succeeded on a closed log")
+	}
+	if err := r.Delete("a"); err == nil {
+		t.Fatal("Delete succeeded on a closed log")
+	}
+	if got := m.all(); len(got) != 1 || got[0] != (item{"a", "a"}) {
+		t.Fatalf("memory = %v, want only the logged item", got)
+	}
+}
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+const apiKeyColumns = `id, user_id, name, prefix, secret_hash, scopes, created_at, expires_at, last_used_at`
+
+type apiKeyRepo struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) user.APIKeyRepository {
+	return &apiKeyRepo{db: db}
+}
+
+func (r *apiKeyRepo) Create(k *user.APIKey) error {
+	scopes, err := json.Marshal(k.Scopes)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO api_keys (`+apiKeyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		k.ID, k.UserID, k.Name, k.Prefix, k.SecretHash, string(scopes), formatTime(k.CreatedAt),
+		nullTime(k.ExpiresAt), nullTime(k.LastUsedAt))
+	return err
+}
+
+func (r *apiKeyRepo) ByPrefix(prefix string) (*user.APIKey, error) {
+	row := r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = ?`, prefix)
+	k, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrAPIKeyNotFound
+	}
+	return k, err
+}
+
+func (r *apiKeyRepo) ListByUser(userID string) ([]user.APIKey, error) {
+	rows, err := r.db.Query(`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]user.APIKey, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *k)
+	}
+	return out, rows.Err()
+}
+
+func (r *apiKeyRepo) Update(k *user.APIKey) error {
+	scopes, err := json.Marshal(k.Scopes)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.Exec(`UPDATE api_keys SET user_id = ?, name = ?, prefix = ?, secret_hash = ?, scopes = ?,
+		created_at = ?, expires_at = ?, last_used_at = ? WHERE id = ?`,
+		k.UserID, k.Name, k.Prefix, k.SecretHash, string(scopes), formatTime(k.CreatedAt),
+		nullTime(k.ExpiresAt), nullTime(k.LastUsedAt), k.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return user.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepo) Delete(id string) error {
+	_, err := r.db.Exec(`DELETE FROM api_keys WHERE id = ?`, id)
+	return err
+}
+
+func (r *apiKeyRepo) DeleteByUser(userID string) error {
+	_, err := r.db.Exec(`DELETE FROM api_keys WHERE user_id = ?`, userID)
+	return err
+}
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*user.APIKey, error) {
+	var (
+		k                   user.APIKey
+		scopes, createdAt   string
+		expiresAt, lastUsed sql.NullString
+	)
+	err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.SecretHash, &scopes, &createdAt, &expiresAt, &lastUsed)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &k.Scopes); err != nil {
+		return nil, err
+	}
+	if k.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if k.ExpiresAt, err = parseNullTime(expiresAt); err != nil {
+		return nil, err
+	}
+	if k.LastUsedAt, err = parseNullTime(lastUsed); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
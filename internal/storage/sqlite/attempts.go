@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+)
+
+type attemptStore struct {
+	db *sql.DB
+}
+
+func NewAttemptStore(db *sql.DB) auth.AttemptStore {
+	return &attemptStore{db: db}
+}
+
+func (st *attemptStore) Get(key string) (auth.Attempts, error) {
+	var (
+		a                        auth.Attempts
+		lastFailure, lockedUntil string
+	)
+	err := st.db.QueryRow(`SELECT key, failures, last_failure, locked_until FROM login_attempts WHERE key = ?`, key).
+		Scan(&a.Key, &a.Failures, &lastFailure, &lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.Attempts{}, nil
+	}
+	if err != nil {
+		return auth.Attempts{}, err
+	}
+	if a.LastFailure, err = parseTime(lastFailure); err != nil {
+		return auth.Attempts{}, err
+	}
+	if a.LockedUntil, err = parseTime(lockedUntil); err != nil {
+		return auth.Attempts{}, err
+	}
+	return a, nil
+}
+
+func (st *attemptStore) Put(a auth.Attempts) error {
+	_, err := st.db.Exec(`INSERT INTO login_attempts (key, failures, last_failure, locked_until) VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET failures = excluded.failures, last_failure = excluded.last_failure,
+		locked_until = excluded.locked_until`,
+		a.Key, a.Failures, formatTime(a.LastFailure), formatTime(a.LockedUntil))
+	return err
+}
+
+func (st *attemptStore) Delete(key string) error {
+	_, err := st.db.Exec(`DELETE FROM login_attempts WHERE key = ?`, key)
+	return err
+}
+
+func (st *attemptStore) DeleteStale(before time.Time) error {
+	ts := formatTime(before)
+	_, err := st.db.Exec(`DELETE FROM login_attempts WHERE last_failure < ? AND locked_until < ?`, ts, ts)
+	return err
+}
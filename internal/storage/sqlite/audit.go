@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Gab-Mello/service-finder/internal/audit"
+)
+
+const auditColumns = `id, at, actor_id, action, target_id, details`
+
+type auditRepo struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) audit.Repository {
+	return &auditRepo{db: db}
+}
+
+func (r *auditRepo) Append(e *audit.Entry) error {
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO audit_log (`+auditColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		e.ID, formatTime(e.At), e.ActorID, e.Action, e.TargetID, string(details))
+	return err
+}
+
+func (r *auditRepo) List(q audit.Query) ([]audit.Entry, error) {
+	rows, err := r.db.Query(`SELECT `+auditColumns+` FROM audit_log
+		WHERE (? = '' OR actor_id = ?) AND (? = '' OR target_id = ?)
+		ORDER BY rowid DESC LIMIT ?`,
+		q.ActorID, q.ActorID, q.TargetID, q.TargetID, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]audit.Entry, 0)
+	for rows.Next() {
+		var (
+			e       audit.Entry
+			at      string
+			details string
+		)
+		if err := rows.Scan(&e.ID, &at, &e.ActorID, &e.Action, &e.TargetID, &details); err != nil {
+			return nil, err
+		}
+		if e.At, err = parseTime(at); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(details), &e.Details); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+const identityColumns = `issuer, subject, user_id, email, created_at`
+
+type identityRepo struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) user.IdentityRepository {
+	return &identityRepo{db: db}
+}
+
+func (r *identityRepo) Create(id *user.Identity) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var n int
+	err = tx.QueryRow(`SELECT COUNT(1) FROM user_identities WHERE issuer = ? AND subject = ?`, id.Issuer, id.Subject).Scan(&n)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return user.ErrIdentityTaken
+	}
+
+	_, err = tx.Exec(`INSERT INTO user_identities (`+identityColumns+`) VALUES (?, ?, ?, ?, ?)`,
+		id.Issuer, id.Subject, id.UserID, id.Email, formatTime(id.CreatedAt))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *identityRepo) BySubject(issuer, subject string) (*user.Identity, error) {
+	row := r.db.QueryRow(`SELECT `+identityColumns+` FROM user_identities WHERE issuer = ? AND subject = ?`,
+		issuer, subject)
+	id, err := scanIdentity(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrNotFound
+	}
+	return id, err
+}
+
+func (r *identityRepo) ListByUser(userID string) ([]user.Identity, error) {
+	rows, err := r.db.Query(`SELECT `+identityColumns+` FROM user_identities WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]user.Identity, 0)
+	for rows.Next() {
+		id, err := scanIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *id)
+	}
+	return out, rows.Err()
+}
+
+func (r *identityRepo) DeleteByUser(userID string) error {
+	_, err := r.db.Exec(`DELETE FROM user_identities WHERE user_id = ?`, userID)
+	return err
+}
+
+func scanIdentity(row interface{ Scan(...any) error }) (*user.Identity, error) {
+	var (
+		id        user.Identity
+		createdAt string
+	)
+	if err := row.Scan(&id.Issuer, &id.Subject, &id.UserID, &id.Email, &createdAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if id.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
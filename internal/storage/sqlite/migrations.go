@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order and tracked through PRAGMA user_version,
+// so a new schema change is always appended, never edited in place.
+var migrations = []string{
+	`CREATE TABLE users (
+		id            TEXT PRIMARY KEY,
+		name          TEXT NOT NULL,
+		email         TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		role          TEXT NOT NULL,
+		provider      TEXT,
+		created_at    TEXT NOT NULL,
+		updated_at    TEXT NOT NULL
+	);
+
+	CREATE TABLE postings (
+		id            TEXT PRIMARY KEY,
+		provider_id   TEXT NOT NULL,
+		provider_name TEXT NOT NULL,
+		title         TEXT NOT NULL,
+		description   TEXT NOT NULL,
+		price         INTEGER NOT NULL,
+		category      TEXT NOT NULL,
+		city          TEXT NOT NULL,
+		district      TEXT NOT NULL,
+		archived      INTEGER NOT NULL DEFAULT 0,
+		created_at    TEXT NOT NULL,
+		updated_at    TEXT NOT NULL
+	);
+	CREATE INDEX postings_provider_idx ON postings (provider_id);
+
+	CREATE TABLE orders (
+		id           TEXT PRIMARY KEY,
+		posting_id   TEXT NOT NULL,
+		client_id    TEXT NOT NULL,
+		provider_id  TEXT NOT NULL,
+		scheduled_at TEXT,
+		status       TEXT NOT NULL,
+		history      TEXT NOT NULL,
+		created_at   TEXT NOT NULL,
+		updated_at   TEXT NOT NULL
+	);
+	CREATE INDEX orders_client_idx ON orders (client_id);
+	CREATE INDEX orders_provider_idx ON orders (provider_id);
+
+	CREATE TABLE reviews (
+		order_id    TEXT PRIMARY KEY,
+		client_id   TEXT NOT NULL,
+		provider_id TEXT NOT NULL,
+		stars       INTEGER NOT NULL,
+		comment     TEXT NOT NULL,
+		created_at  TEXT NOT NULL,
+		updated_at  TEXT NOT NULL
+	);
+	CREATE INDEX reviews_provider_idx ON reviews (provider_id);`,
+
+	`CREATE TABLE sessions (
+		id           TEXT PRIMARY KEY,
+		user_id      TEXT NOT NULL,
+		remember     INTEGER NOT NULL DEFAULT 0,
+		created_at   TEXT NOT NULL,
+		last_seen_at TEXT NOT NULL,
+		exp_at       TEXT NOT NULL,
+		max_exp_at   TEXT NOT NULL
+	);
+	CREATE INDEX sessions_user_idx ON sessions (user_id);`,
+
+	`ALTER TABLE sessions ADD COLUMN kind TEXT NOT NULL DEFAULT '';
+	ALTER TABLE sessions ADD COLUMN family_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE sessions ADD COLUMN rotated INTEGER NOT NULL DEFAULT 0;`,
+
+	`ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+	ALTER TABLE sessions ADD COLUMN ip TEXT NOT NULL DEFAULT '';`,
+
+	`CREATE TABLE user_tokens (
+		hash       TEXT PRIMARY KEY,
+		user_id    TEXT NOT NULL,
+		purpose    TEXT NOT NULL,
+		expires_at TEXT NOT NULL,
+		created_at TEXT NOT NULL
+	);
+	CREATE INDEX user_tokens_user_idx ON user_tokens (user_id, purpose);`,
+
+	// accounts that existed before verification was introduced are
+	// considered verified
+	`ALTER TABLE users ADD COLUMN email_verified INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE users ADD COLUMN verification_sent_at TEXT;`,
+
+	`ALTER TABLE users ADD COLUMN two_factor TEXT;`,
+
+	`CREATE TABLE login_attempts (
+		key          TEXT PRIMARY KEY,
+		failures     INTEGER NOT NULL,
+		last_failure TEXT NOT NULL,
+		locked_until TEXT NOT NULL
+	);`,
+
+	`CREATE TABLE user_identities (
+		issuer     TEXT NOT NULL,
+		subject    TEXT NOT NULL,
+		user_id    TEXT NOT NULL,
+		email      TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (issuer, subject)
+	);
+	CREATE INDEX user_identities_user_idx ON user_identities (user_id);`,
+
+	`ALTER TABLE users ADD COLUMN pending_email TEXT NOT NULL DEFAULT '';`,
+
+	`ALTER TABLE users ADD COLUMN customer TEXT;
+	ALTER TABLE orders ADD COLUMN address TEXT;`,
+
+	// accounts get the capabilities their registration role grants
+	`ALTER TABLE users ADD COLUMN roles TEXT NOT NULL DEFAULT '[]';
+	UPDATE users SET roles = CASE role
+		WHEN 'provider' THEN '["customer","provider"]'
+		ELSE json_array(role)
+	END;`,
+
+	`ALTER TABLE users ADD COLUMN suspension TEXT;
+	CREATE TABLE audit_log (
+		id        TEXT PRIMARY KEY,
+		at        TEXT NOT NULL,
+		actor_id  TEXT NOT NULL,
+		action    TEXT NOT NULL,
+		target_id TEXT NOT NULL,
+		details   TEXT NOT NULL
+	);
+	CREATE INDEX audit_log_actor_idx ON audit_log (actor_id);
+	CREATE INDEX audit_log_target_idx ON audit_log (target_id);`,
+
+	`ALTER TABLE sessions ADD COLUMN impersonator_id TEXT NOT NULL DEFAULT '';`,
+
+	`CREATE TABLE api_keys (
+		id           TEXT PRIMARY KEY,
+		user_id      TEXT NOT NULL,
+		name         TEXT NOT NULL,
+		prefix       TEXT NOT NULL UNIQUE,
+		secret_hash  TEXT NOT NULL,
+		scopes       TEXT NOT NULL,
+		created_at   TEXT NOT NULL,
+		expires_at   TEXT,
+		last_used_at TEXT
+	);
+	CREATE INDEX api_keys_user_idx ON api_keys (user_id);`,
+
+	`ALTER TABLE users ADD COLUMN erasure_alias TEXT NOT NULL DEFAULT '';`,
+}
+
+func Migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/Gab-Mello/service-finder/internal/order"
+	"github.com/Gab-Mello/service-finder/internal/ports"
+)
+
+const orderColumns = `id, posting_id, client_id, provider_id, scheduled_at, address, status, history,
+	created_at, updated_at`
+
+type orderRepo struct {
+	db *sql.DB
+}
+
+func NewOrderRepository(db *sql.DB) order.Repository {
+	return &orderRepo{db: db}
+}
+
+func (r *orderRepo) Create(o *order.Order) error {
+	history, err := json.Marshal(o.History)
+	if err != nil {
+		return err
+	}
+	address, err := marshalAddress(o.Address)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO orders (`+orderColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		o.ID, o.PostingID, o.ClientID, o.ProviderID, nullTime(o.ScheduledAt), address, string(o.Status),
+		string(history), formatTime(o.CreatedAt), formatTime(o.UpdatedAt))
+	return err
+}
+
+func (r *orderRepo) ByID(id string) (*order.Order, error) {
+	row := r.db.QueryRow(`SELECT `+orderColumns+` FROM orders WHERE id = ?`, id)
+	o, err := scanOrder(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, order.ErrNotFound
+	}
+	return o, err
+}
+
+func (r *orderRepo) Update(o *order.Order) error {
+	history, err := json.Marshal(o.History)
+	if err != nil {
+		return err
+	}
+	address, err := marshalAddress(o.Address)
+	if err != nil {
+		return err
+	}
+	res, err := r.db.Exec(`UPDATE orders SET posting_id = ?, client_id = ?, provider_id = ?, scheduled_at = ?,
+		address = ?, status = ?, history = ?, created_at = ?, updated_at = ? WHERE id = ?`,
+		o.PostingID, o.ClientID, o.ProviderID, nullTime(o.ScheduledAt), address, string(o.Status),
+		string(history), formatTime(o.CreatedAt), formatTime(o.UpdatedAt), o.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return order.ErrNotFound
+	}
+	return nil
+}
+
+func (r *orderRepo) ListMine(userID string) ([]order.Order, error) {
+	rows, err := r.db.Query(`SELECT `+orderColumns+` FROM orders
+		WHERE client_id = ? OR provider_id = ? ORDER BY rowid`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]order.Order, 0)
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *o)
+	}
+	return out, rows.Err()
+}
+
+func scanOrder(row interface{ Scan(...any) error }) (*order.Order, error) {
+	var (
+		o                    order.Order
+		status, history      string
+		scheduledAt          sql.NullString
+		address              sql.NullString
+		createdAt, updatedAt string
+	)
+	err := row.Scan(&o.ID, &o.PostingID, &o.ClientID, &o.ProviderID, &scheduledAt, &address, &status,
+		&history, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Status = order.Status(status)
+	if err := json.Unmarshal([]byte(history), &o.History); err != nil {
+		return nil, err
+	}
+	if o.ScheduledAt, err = parseNullTime(scheduledAt); err != nil {
+		return nil, err
+	}
+	if address.Valid {
+		o.Address = &ports.Address{}
+		if err := json.Unmarshal([]byte(address.String), o.Address); err != nil {
+			return nil, err
+		}
+	}
+	if o.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if o.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func marshalAddress(a *ports.Address) (sql.NullString, error) {
+	if a == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Gab-Mello/service-finder/internal/posting"
+)
+
+const postingColumns = `id, provider_id, provider_name, title, description, price,
+	category, city, district, archived, created_at, updated_at`
+
+type postingRepo struct {
+	db *sql.DB
+}
+
+func NewPostingRepository(db *sql.DB) posting.Repository {
+	return &postingRepo{db: db}
+}
+
+func (r *postingRepo) Create(p *posting.Posting) error {
+	res, err := r.db.Exec(`INSERT INTO postings (`+postingColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING`,
+		p.ID, p.ProviderID, p.ProviderName, p.Title, p.Description, p.Price,
+		p.Category, p.City, p.District, p.Archived, formatTime(p.CreatedAt), formatTime(p.UpdatedAt))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return posting.ErrInvalidFields // ID already exists
+	}
+	return nil
+}
+
+func (r *postingRepo) Update(p *posting.Posting) error {
+	res, err := r.db.Exec(`UPDATE postings SET provider_id = ?, provider_name = ?, title = ?, description = ?,
+		price = ?, category = ?, city = ?, district = ?, archived = ?, created_at = ?, updated_at = ?
+		WHERE id = ?`,
+		p.ProviderID, p.ProviderName, p.Title, p.Description, p.Price,
+		p.Category, p.City, p.District, p.Archived, formatTime(p.CreatedAt), formatTime(p.UpdatedAt), p.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return posting.ErrNotFound
+	}
+	return nil
+}
+
+func (r *postingRepo) ByID(id string) (*posting.Posting, error) {
+	row := r.db.QueryRow(`SELECT `+postingColumns+` FROM postings WHERE id = ?`, id)
+	p, err := scanPosting(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, posting.ErrNotFound
+	}
+	return p, err
+}
+
+func (r *postingRepo) ListByProvider(providerID string) ([]posting.Posting, error) {
+	return r.list(`SELECT `+postingColumns+` FROM postings WHERE provider_id = ? ORDER BY rowid`, providerID)
+}
+
+func (r *postingRepo) ListPublic() ([]posting.Posting, error) {
+	return r.list(`SELECT ` + postingColumns + ` FROM postings WHERE archived = 0 ORDER BY rowid`)
+}
+
+func (r *postingRepo) list(query string, args ...any) ([]posting.Posting, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]posting.Posting, 0)
+	for rows.Next() {
+		p, err := scanPosting(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+func scanPosting(row interface{ Scan(...any) error }) (*posting.Posting, error) {
+	var (
+		p                    posting.Posting
+		createdAt, updatedAt string
+	)
+	err := row.Scan(&p.ID, &p.ProviderID, &p.ProviderName, &p.Title, &p.Description, &p.Price,
+		&p.Category, &p.City, &p.District, &p.Archived, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if p.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if p.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
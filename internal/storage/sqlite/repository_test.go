@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/audit"
+	"github.com/Gab-Mello/service-finder/internal/audit/audittest"
+	"github.com/Gab-Mello/service-finder/internal/order"
+	"github.com/Gab-Mello/service-finder/internal/order/ordertest"
+	"github.com/Gab-Mello/service-finder/internal/posting"
+	"github.com/Gab-Mello/service-finder/internal/posting/postingtest"
+	"github.com/Gab-Mello/service-finder/internal/review"
+	"github.com/Gab-Mello/service-finder/internal/review/reviewtest"
+	"github.com/Gab-Mello/service-finder/internal/user"
+	"github.com/Gab-Mello/service-finder/internal/user/usertest"
+)
+
+// openDB opens a migrated database of its own for each repository the
+// suites ask for.
+func openDB(t *testing.T) *sql.DB {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestUserRepository(t *testing.T) {
+	usertest.TestRepository(t, func() user.Repository { return NewUserRepository(openDB(t)) })
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	usertest.TestAPIKeyRepository(t, func() user.APIKeyRepository { return NewAPIKeyRepository(openDB(t)) })
+}
+
+func TestPostingRepository(t *testing.T) {
+	postingtest.TestRepository(t, func() posting.Repository { return NewPostingRepository(openDB(t)) })
+}
+
+func TestOrderRepository(t *testing.T) {
+	ordertest.TestRepository(t, func() order.Repository { return NewOrderRepository(openDB(t)) })
+}
+
+func TestReviewRepository(t *testing.T) {
+	reviewtest.TestRepository(t, func() review.Repository { return NewReviewRepository(openDB(t)) })
+}
+
+func TestAuditRepository(t *testing.T) {
+	audittest.TestRepository(t, func() audit.Repository { return NewAuditRepository(openDB(t)) })
+}
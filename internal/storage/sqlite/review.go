@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/Gab-Mello/service-finder/internal/review"
+)
+
+const reviewColumns = `order_id, client_id, provider_id, stars, comment, created_at, updated_at`
+
+// summaryBatch bounds the provider IDs bound in one Summaries query, well
+// under SQLite's limit on host parameters.
+const summaryBatch = 500
+
+type reviewRepo struct {
+	db *sql.DB
+}
+
+func NewReviewRepository(db *sql.DB) review.Repository {
+	return &reviewRepo{db: db}
+}
+
+func (r *reviewRepo) Create(rv *review.Review) error {
+	res, err := r.db.Exec(`INSERT INTO reviews (`+reviewColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (order_id) DO NOTHING`,
+		rv.OrderID, rv.ClientID, rv.ProviderID, rv.Stars, rv.Comment,
+		formatTime(rv.CreatedAt), formatTime(rv.UpdatedAt))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return review.ErrAlreadyExists
+	}
+	return nil
+}
+
+func (r *reviewRepo) ByOrderID(orderID string) (*review.Review, error) {
+	row := r.db.QueryRow(`SELECT `+reviewColumns+` FROM reviews WHERE order_id = ?`, orderID)
+	rv, err := scanReview(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, review.ErrNotFound
+	}
+	return rv, err
+}
+
+func (r *reviewRepo) Update(rv *review.Review) error {
+	res, err := r.db.Exec(`UPDATE reviews SET client_id = ?, provider_id = ?, stars = ?, comment = ?,
+		created_at = ?, updated_at = ? WHERE order_id = ?`,
+		rv.ClientID, rv.ProviderID, rv.Stars, rv.Comment,
+		formatTime(rv.CreatedAt), formatTime(rv.UpdatedAt), rv.OrderID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return review.ErrNotFound
+	}
+	return nil
+}
+
+func (r *reviewRepo) ListByProvider(providerID string) ([]review.Review, error) {
+	rows, err := r.db.Query(`SELECT `+reviewColumns+` FROM reviews WHERE provider_id = ? ORDER BY rowid`, providerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]review.Review, 0)
+	for rows.Next() {
+		rv, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rv)
+	}
+	return out, rows.Err()
+}
+
+func (r *reviewRepo) Summaries(providerIDs []string) (map[string]review.Summary, error) {
+	out := make(map[string]review.Summary, len(providerIDs))
+	for len(providerIDs) > 0 {
+		batch := providerIDs[:min(len(providerIDs), summaryBatch)]
+		providerIDs = providerIDs[len(batch):]
+
+		args := make([]any, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		rows, err := r.db.Query(`SELECT provider_id, COUNT(*), SUM(stars) FROM reviews
+			WHERE provider_id IN (?`+strings.Repeat(", ?", len(batch)-1)+`) GROUP BY provider_id`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var (
+				id  string
+				sum review.Summary
+			)
+			if err := rows.Scan(&id, &sum.Count, &sum.Stars); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			out[id] = sum
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func scanReview(row interface{ Scan(...any) error }) (*review.Review, error) {
+	var (
+		rv                   review.Review
+		createdAt, updatedAt string
+	)
+	err := row.Scan(&rv.OrderID, &rv.ClientID, &rv.ProviderID, &rv.Stars, &rv.Comment, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if rv.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if rv.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+)
+
+const sessionColumns = `id, user_id, kind, family_id, rotated, remember, user_agent, ip,
+	created_at, last_seen_at, exp_at, max_exp_at, impersonator_id`
+
+type sessionStore struct {
+	db *sql.DB
+}
+
+func NewSessionStore(db *sql.DB) auth.SessionStore {
+	return &sessionStore{db: db}
+}
+
+func (st *sessionStore) Create(s *auth.Session) error {
+	_, err := st.db.Exec(`INSERT INTO sessions (`+sessionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, s.UserID, string(s.Kind), s.FamilyID, s.Rotated, s.Remember, s.UserAgent, s.IP, formatTime(s.CreatedAt), formatTime(s.LastSeenAt),
+		formatTime(s.ExpAt), formatTime(s.MaxExpAt), s.ImpersonatorID)
+	return err
+}
+
+func (st *sessionStore) ByID(id string) (*auth.Session, error) {
+	row := st.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, id)
+	s, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrSessionNotFound
+	}
+	return s, err
+}
+
+func (st *sessionStore) Update(s *auth.Session) error {
+	res, err := st.db.Exec(`UPDATE sessions SET user_id = ?, kind = ?, family_id = ?, rotated = ?, remember = ?,
+		user_agent = ?, ip = ?, created_at = ?, last_seen_at = ?, exp_at = ?, max_exp_at = ?, impersonator_id = ? WHERE id = ?`,
+		s.UserID, string(s.Kind), s.FamilyID, s.Rotated, s.Remember, s.UserAgent, s.IP, formatTime(s.CreatedAt), formatTime(s.LastSeenAt),
+		formatTime(s.ExpAt), formatTime(s.MaxExpAt), s.ImpersonatorID, s.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return auth.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (st *sessionStore) Delete(id string) error {
+	_, err := st.db.Exec(`DELETE FROM sessions WHERE id = ?`, id)
+	return err
+}
+
+func (st *sessionStore) DeleteExpired(now time.Time) error {
+	ts := formatTime(now)
+	_, err := st.db.Exec(`DELETE FROM sessions WHERE exp_at < ? OR max_exp_at < ?`, ts, ts)
+	return err
+}
+
+func (st *sessionStore) ListByUser(userID string) ([]auth.Session, error) {
+	rows, err := st.db.Query(`SELECT `+sessionColumns+` FROM sessions WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]auth.Session, 0)
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
+
+func scanSession(row interface{ Scan(...any) error }) (*auth.Session, error) {
+	var (
+		s                                    auth.Session
+		kind                                 string
+		createdAt, lastSeenAt, expAt, maxExp string
+	)
+	err := row.Scan(&s.ID, &s.UserID, &kind, &s.FamilyID, &s.Rotated, &s.Remember, &s.UserAgent, &s.IP,
+		&createdAt, &lastSeenAt, &expAt, &maxExp, &s.ImpersonatorID)
+	if err != nil {
+		return nil, err
+	}
+	s.Kind = auth.TokenKind(kind)
+	if s.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if s.LastSeenAt, err = parseTime(lastSeenAt); err != nil {
+		return nil, err
+	}
+	if s.ExpAt, err = parseTime(expAt); err != nil {
+		return nil, err
+	}
+	if s.MaxExpAt, err = parseTime(maxExp); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
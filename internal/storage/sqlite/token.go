@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+type tokenRepo struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) user.TokenRepository {
+	return &tokenRepo{db: db}
+}
+
+func (r *tokenRepo) Create(t *user.Token) error {
+	_, err := r.db.Exec(`INSERT INTO user_tokens (hash, user_id, purpose, expires_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		t.Hash, t.UserID, string(t.Purpose), formatTime(t.ExpiresAt), formatTime(t.CreatedAt))
+	return err
+}
+
+func (r *tokenRepo) ByHash(hash string) (*user.Token, error) {
+	var (
+		t                    user.Token
+		purpose              string
+		expiresAt, createdAt string
+	)
+	err := r.db.QueryRow(`SELECT hash, user_id, purpose, expires_at, created_at FROM user_tokens WHERE hash = ?`, hash).
+		Scan(&t.Hash, &t.UserID, &purpose, &expiresAt, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.Purpose = user.TokenPurpose(purpose)
+	if t.ExpiresAt, err = parseTime(expiresAt); err != nil {
+		return nil, err
+	}
+	if t.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *tokenRepo) DeleteByUser(userID string, purpose user.TokenPurpose) error {
+	_, err := r.db.Exec(`DELETE FROM user_tokens WHERE user_id = ? AND purpose = ?`, userID, string(purpose))
+	return err
+}
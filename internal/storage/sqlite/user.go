@@ -0,0 +1,256 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+const userColumns = `id, name, email, password_hash, role, roles, email_verified, verification_sent_at,
+	pending_email, two_factor, provider, customer, suspension, erasure_alias, created_at, updated_at`
+
+type userRepo struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) user.Repository {
+	return &userRepo{db: db}
+}
+
+func (r *userRepo) Create(u *user.User) error {
+	provider, err := marshalProvider(u.Provider)
+	if err != nil {
+		return err
+	}
+	roles, err := json.Marshal(u.Capabilities())
+	if err != nil {
+		return err
+	}
+	customer, err := marshalCustomer(u.Customer)
+	if err != nil {
+		return err
+	}
+	suspension, err := marshalSuspension(u.Suspension)
+	if err != nil {
+		return err
+	}
+	twoFactor, err := marshalTwoFactor(u.TwoFactor)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if taken, err := emailExists(tx, u.Email); err != nil {
+		return err
+	} else if taken {
+		return user.ErrEmailTaken
+	}
+
+	_, err = tx.Exec(`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		u.ID, u.Name, u.Email, u.PasswordHash, string(u.Role), string(roles), u.EmailVerified, nullTime(u.VerificationSentAt),
+		u.PendingEmail, twoFactor, provider, customer, suspension, u.ErasureAlias,
+		formatTime(u.CreatedAt), formatTime(u.UpdatedAt))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *userRepo) ByEmail(email string) (*user.User, error) {
+	row := r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE email = ?`, email)
+	return scanUser(row)
+}
+
+func (r *userRepo) ByID(id string) (*user.User, error) {
+	row := r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ?`, id)
+	return scanUser(row)
+}
+
+func (r *userRepo) Update(u *user.User) error {
+	provider, err := marshalProvider(u.Provider)
+	if err != nil {
+		return err
+	}
+	roles, err := json.Marshal(u.Capabilities())
+	if err != nil {
+		return err
+	}
+	customer, err := marshalCustomer(u.Customer)
+	if err != nil {
+		return err
+	}
+	suspension, err := marshalSuspension(u.Suspension)
+	if err != nil {
+		return err
+	}
+	twoFactor, err := marshalTwoFactor(u.TwoFactor)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldEmail string
+	err = tx.QueryRow(`SELECT email FROM users WHERE id = ?`, u.ID).Scan(&oldEmail)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if oldEmail != u.Email {
+		if taken, err := emailExists(tx, u.Email); err != nil {
+			return err
+		} else if taken {
+			return user.ErrEmailTaken
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE users SET name = ?, email = ?, password_hash = ?, role = ?, roles = ?, email_verified = ?,
+		verification_sent_at = ?, pending_email = ?, two_factor = ?, provider = ?, customer = ?, suspension = ?, erasure_alias = ?,
+		created_at = ?, updated_at = ? WHERE id = ?`,
+		u.Name, u.Email, u.PasswordHash, string(u.Role), string(roles), u.EmailVerified, nullTime(u.VerificationSentAt),
+		u.PendingEmail, twoFactor, provider, customer, suspension, u.ErasureAlias,
+		formatTime(u.CreatedAt), formatTime(u.UpdatedAt), u.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *userRepo) Delete(id string) error {
+	res, err := r.db.Exec(`DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return user.ErrNotFound
+	}
+	return nil
+}
+
+func emailExists(tx *sql.Tx, email string) (bool, error) {
+	var n int
+	if err := tx.QueryRow(`SELECT COUNT(1) FROM users WHERE email = ?`, email).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func marshalProvider(p *user.ProviderProfile) (sql.NullString, error) {
+	if p == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func marshalCustomer(c *user.CustomerProfile) (sql.NullString, error) {
+	if c == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func marshalSuspension(sp *user.Suspension) (sql.NullString, error) {
+	if sp == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(sp)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// marshalTwoFactor stores NULL for accounts that never started enrollment.
+func marshalTwoFactor(tf user.TwoFactor) (sql.NullString, error) {
+	if tf.Secret == "" {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(tf)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func scanUser(row interface{ Scan(...any) error }) (*user.User, error) {
+	var (
+		u                    user.User
+		role, roles          string
+		verificationSentAt   sql.NullString
+		twoFactor            sql.NullString
+		provider             sql.NullString
+		customer             sql.NullString
+		suspension           sql.NullString
+		createdAt, updatedAt string
+	)
+	err := row.Scan(&u.ID, &u.Name, &u.Email, &u.PasswordHash, &role, &roles, &u.EmailVerified, &verificationSentAt,
+		&u.PendingEmail, &twoFactor, &provider, &customer, &suspension, &u.ErasureAlias, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	u.Role = user.Role(role)
+	if err := json.Unmarshal([]byte(roles), &u.Roles); err != nil {
+		return nil, err
+	}
+	if u.VerificationSentAt, err = parseNullTime(verificationSentAt); err != nil {
+		return nil, err
+	}
+	if twoFactor.Valid {
+		if err := json.Unmarshal([]byte(twoFactor.String), &u.TwoFactor); err != nil {
+			return nil, err
+		}
+	}
+	if provider.Valid {
+		u.Provider = &user.ProviderProfile{}
+		if err := json.Unmarshal([]byte(provider.String), u.Provider); err != nil {
+			return nil, err
+		}
+	}
+	if customer.Valid {
+		u.Customer = &user.CustomerProfile{}
+		if err := json.Unmarshal([]byte(customer.String), u.Customer); err != nil {
+			return nil, err
+		}
+	}
+	if suspension.Valid {
+		u.Suspension = &user.Suspension{}
+		if err := json.Unmarshal([]byte(suspension.String), u.Suspension); err != nil {
+			return nil, err
+		}
+	}
+	if u.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if u.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
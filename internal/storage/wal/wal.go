@@ -0,0 +1,291 @@
+// Package wal persists an in-memory store as a compacted snapshot plus an
+// append-only log of the mutations applied since that snapshot.
+//
+// Records are framed as [length uint32][crc32 uint32][gob payload]. A crash
+// in the middle of an append leaves a torn final frame; Open with
+// Options.Recover truncates it, otherwise Replay reports ErrTornRecord.
+package wal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	headerSize           = 8
+	defaultSnapshotEvery = 1000
+)
+
+var (
+	ErrTornRecord = errors.New("wal: torn final record")
+	ErrCorrupt    = errors.New("wal: corrupt record")
+)
+
+type Options struct {
+	// SnapshotEvery is how many records may be appended before SnapshotDue
+	// reports true. Zero means 1000.
+	SnapshotEvery int
+	// Recover truncates a torn final record during Replay instead of failing.
+	Recover bool
+}
+
+// Decoder decodes the payload of a snapshot or record into v.
+type Decoder func(v any) error
+
+type Log struct {
+	mu      sync.Mutex
+	dir     string
+	name    string
+	f       *os.File
+	seq     uint64
+	pending int
+	opts    Options
+}
+
+type entry struct {
+	Seq  uint64
+	Op   string
+	Data []byte
+}
+
+type snapshot struct {
+	Seq  uint64
+	Data []byte
+}
+
+func Open(dir, name string, opts Options) (*Log, error) {
+	if opts.SnapshotEvery <= 0 {
+		opts.SnapshotEvery = defaultSnapshotEvery
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create wal directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, name+".wal"), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wal: %w", err)
+	}
+	return &Log{dir: dir, name: name, f: f, opts: opts}, nil
+}
+
+// Replay feeds the latest snapshot to restore (if one exists) and then every
+// logged record written after it to apply, in order. It must be called once,
+// before the first Append.
+func (l *Log) Replay(restore func(Decoder) error, apply func(op string, dec Decoder) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	snapSeq, err := l.readSnapshot(restore)
+	if err != nil {
+		return err
+	}
+	l.seq = snapSeq
+
+	data, err := io.ReadAll(io.NewSectionReader(l.f, 0, 1<<62))
+	if err != nil {
+		return fmt.Errorf("failed to read wal: %w", err)
+	}
+
+	off := 0
+	for off < len(data) {
+		payload, n, err := readFrame(data[off:])
+		if errors.Is(err, ErrTornRecord) {
+			if !l.opts.Recover {
+				return fmt.Errorf("%s: %w at offset %d", l.name, err, off)
+			}
+			if err := l.f.Truncate(int64(off)); err != nil {
+				return fmt.Errorf("failed to truncate torn record: %w", err)
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w at offset %d", l.name, err, off)
+		}
+		off += n
+
+		var e entry
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&e); err != nil {
+			return fmt.Errorf("%s: %w at offset %d: %v", l.name, ErrCorrupt, off, err)
+		}
+		// records already folded into the snapshot survive only when a crash
+		// hit between writing the snapshot and truncating the log
+		if e.Seq <= snapSeq {
+			continue
+		}
+		if err := apply(e.Op, decoderFor(e.Data)); err != nil {
+			return fmt.Errorf("%s: failed to apply record %d: %w", l.name, e.Seq, err)
+		}
+		l.seq = e.Seq
+		l.pending++
+	}
+	return nil
+}
+
+// Append durably records a mutation. The write is fsynced before returning.
+func (l *Log) Append(op string, v any) error {
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(v); err != nil {
+		return fmt.Errorf("failed to encode wal record: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry{Seq: l.seq + 1, Op: op, Data: data.Bytes()}); err != nil {
+		return fmt.Errorf("failed to encode wal record: %w", err)
+	}
+	if _, err := l.f.Write(frame(buf.Bytes())); err != nil {
+		return fmt.Errorf("failed to write wal record: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync wal: %w", err)
+	}
+	l.seq++
+	l.pending++
+	return nil
+}
+
+func (l *Log) SnapshotDue() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pending >= l.opts.SnapshotEvery
+}
+
+// Snapshot replaces the snapshot with state and empties the log. The caller
+// must make sure no Append runs concurrently, so that state reflects exactly
+// the records logged so far.
+func (l *Log) Snapshot(state any) error {
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(state); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snapshot{Seq: l.seq, Data: data.Bytes()}); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	path := l.snapshotPath()
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, frame(buf.Bytes())); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to install snapshot: %w", err)
+	}
+	syncDir(l.dir)
+
+	if err := l.f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to compact wal: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync wal: %w", err)
+	}
+	l.pending = 0
+	return nil
+}
+
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+func (l *Log) snapshotPath() string {
+	return filepath.Join(l.dir, l.name+".snapshot")
+}
+
+func (l *Log) readSnapshot(restore func(Decoder) error) (uint64, error) {
+	data, err := os.ReadFile(l.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	// snapshots are installed by rename, so unlike the log they are never torn
+	payload, n, err := readFrame(data)
+	if err != nil || n != len(data) {
+		return 0, fmt.Errorf("%s snapshot: %w", l.name, ErrCorrupt)
+	}
+	var s snapshot
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&s); err != nil {
+		return 0, fmt.Errorf("%s snapshot: %w: %v", l.name, ErrCorrupt, err)
+	}
+	if err := restore(decoderFor(s.Data)); err != nil {
+		return 0, fmt.Errorf("%s: failed to restore snapshot: %w", l.name, err)
+	}
+	return s.Seq, nil
+}
+
+func decoderFor(data []byte) Decoder {
+	return func(v any) error {
+		return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+	}
+}
+
+func frame(payload []byte) []byte {
+	out := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(out[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(out[4:8], crc32.ChecksumIEEE(payload))
+	copy(out[headerSize:], payload)
+	return out
+}
+
+// readFrame returns the payload of the frame at the start of b and the number
+// of bytes it occupies. A frame cut short by the end of b, or a checksum
+// mismatch on the very last frame, is reported as ErrTornRecord.
+func readFrame(b []byte) ([]byte, int, error) {
+	if len(b) < headerSize {
+		return nil, 0, ErrTornRecord
+	}
+	size := int(binary.LittleEndian.Uint32(b[0:4]))
+	sum := binary.LittleEndian.Uint32(b[4:8])
+	end := headerSize + size
+	if len(b) < end {
+		return nil, 0, ErrTornRecord
+	}
+	payload := b[headerSize:end]
+	if crc32.ChecksumIEEE(payload) != sum {
+		if end == len(b) {
+			return nil, 0, ErrTornRecord
+		}
+		return nil, 0, ErrCorrupt
+	}
+	return payload, end, nil
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,28 @@
+// Package waltest builds WAL-backed repositories for the repository
+// conformance suites.
+package waltest
+
+import (
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
+)
+
+// Repository returns a newRepo for a conformance suite. Each call opens an
+// empty log named name in a temporary directory and hands it to open,
+// usually a package's NewDurable…Repository.
+func Repository[R any](t *testing.T, name string, open func(*wal.Log) (R, error)) func() R {
+	return func() R {
+		t.Helper()
+		w, err := wal.Open(t.TempDir(), name, wal.Options{})
+		if err != nil {
+			t.Fatalf("wal.Open: %v", err)
+		}
+		t.Cleanup(func() { w.Close() })
+		r, err := open(w)
+		if err != nil {
+			t.Fatalf("open %s repository: %v", name, err)
+		}
+		return r
+	}
+}
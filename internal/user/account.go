@@ -0,0 +1,174 @@
+package user
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/Gab-Mello/service-finder/internal/ports"
+)
+
+// ProviderPostings keeps a provider's postings in step with the account:
+// postings carry a copy of the provider name.
+type ProviderPostings interface {
+	RenameProvider(providerID, name string) error
+}
+
+func (s *Service) SetProviderPostings(p ProviderPostings) {
+	s.postings = p
+}
+
+func (s *Service) UpdateName(userID, name string) (*User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrValidation)
+	}
+	if len(name) > maxNameLen {
+		return nil, fmt.Errorf("%w: name is too long", ErrValidation)
+	}
+
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Name == name {
+		return u, nil
+	}
+	u.Name = name
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	if s.postings != nil && u.HasRole(RoleProvider) {
+		if err := s.postings.RenameProvider(u.ID, name); err != nil {
+			log.Printf("failed to rename postings of provider %s: %v", u.ID, err)
+		}
+	}
+	return u, nil
+}
+
+// RequestEmailChange mails a confirmation link to the new address. The
+// account keeps its current email until the link is used.
+func (s *Service) RequestEmailChange(userID string, proof Reauth, email string) error {
+	if s.mailer == nil {
+		return ErrMailerDisabled
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !emailRegex.MatchString(email) {
+		return fmt.Errorf("%w: invalid email format", ErrValidation)
+	}
+
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmIdentity(u, proof); err != nil {
+		return err
+	}
+	if email == u.Email {
+		return fmt.Errorf("%w: this is already your email", ErrValidation)
+	}
+	if _, err := s.repo.ByEmail(email); err == nil {
+		return ErrEmailTaken
+	}
+
+	if err := s.tokens.DeleteByUser(u.ID, PurposeEmailChange); err != nil {
+		return err
+	}
+	token, err := s.issueToken(u.ID, PurposeEmailChange, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	u.PendingEmail = email
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return err
+	}
+
+	if err := s.mailer.Send(ports.Mail{
+		To:      u.Email,
+		Subject: "Alteração de e-mail solicitada",
+		Body: fmt.Sprintf("Olá, %s.\n\nRecebemos um pedido para trocar o e-mail da sua conta para %s. Se não foi você, altere sua senha.\n",
+			u.Name, email),
+	}); err != nil {
+		log.Printf("failed to notify user %s of email change: %v", u.ID, err)
+	}
+	return s.mailer.Send(ports.Mail{
+		To:      email,
+		Subject: "Confirme seu novo e-mail",
+		Body: fmt.Sprintf("Olá, %s.\n\nConfirme seu novo endereço de e-mail acessando o link abaixo:\n\n%s/confirm-email-change?token=%s\n\nO link expira em %d horas.\n",
+			u.Name, s.appURL, url.QueryEscape(token), int(emailVerificationTTL.Hours())),
+	})
+}
+
+// ConfirmEmailChange moves the account to the address the token was mailed
+// to, which is verified by the same act.
+func (s *Service) ConfirmEmailChange(token string) (*User, error) {
+	if s.mailer == nil {
+		return nil, ErrMailerDisabled
+	}
+	t, err := s.consumeToken(token, PurposeEmailChange)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.repo.ByID(t.UserID)
+	if err != nil || u.PendingEmail == "" {
+		return nil, ErrInvalidToken
+	}
+
+	old := u.Email
+	u.Email = u.PendingEmail
+	u.PendingEmail = ""
+	u.EmailVerified = true
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	if s.throttle != nil {
+		if err := s.throttle.Unlock(old); err != nil {
+			log.Printf("failed to clear login failures of user %s: %v", u.ID, err)
+		}
+	}
+	return u, nil
+}
+
+// DeleteAccount erases the user for good. Their sessions end first, then
+// their records in other domains are anonymized (see AddPersonalData),
+// linked identities are released and API keys deleted. The account is
+// removed last, so a failed erasure can be retried after logging in again.
+func (s *Service) DeleteAccount(userID string, proof Reauth) error {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmIdentity(u, proof); err != nil {
+		return err
+	}
+
+	if s.sessions != nil {
+		if err := s.sessions.RevokeAll(u.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.erasePersonalData(u); err != nil {
+		return err
+	}
+	for _, purpose := range []TokenPurpose{PurposePasswordReset, PurposeEmailVerification, PurposeLoginChallenge, PurposeEmailChange} {
+		if err := s.tokens.DeleteByUser(u.ID, purpose); err != nil {
+			return err
+		}
+	}
+	if err := s.identities.DeleteByUser(u.ID); err != nil {
+		return err
+	}
+	if err := s.apiKeys.DeleteByUser(u.ID); err != nil {
+		return err
+	}
+	if s.throttle != nil {
+		if err := s.throttle.Unlock(u.Email); err != nil {
+			log.Printf("failed to clear login failures of user %s: %v", u.ID, err)
+		}
+	}
+	return s.repo.Delete(u.ID)
+}
@@ -0,0 +1,297 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scope is a permission granted to an API key. Routes reachable with API
+// keys declare the scope they need.
+type Scope string
+
+const (
+	ScopePostingsRead  Scope = "postings:read"
+	ScopePostingsWrite Scope = "postings:write"
+	ScopeOrdersRead    Scope = "orders:read"
+	ScopeOrdersWrite   Scope = "orders:write"
+)
+
+var knownScopes = []Scope{ScopePostingsRead, ScopePostingsWrite, ScopeOrdersRead, ScopeOrdersWrite}
+
+const (
+	apiKeyMarker      = "sf"
+	maxAPIKeys        = 20
+	maxAPIKeyNameLen  = 100
+	apiKeyTouchEvery  = time.Minute
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+)
+
+// APIKey lets an integration act as its owner, limited to Scopes. The key
+// handed out once is "sf_<Prefix>_<secret>"; Prefix finds the key and only
+// the SHA-256 of the secret is stored.
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	SecretHash string     `json:"-"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+func (k *APIKey) HasScope(s Scope) bool {
+	return slices.Contains(k.Scopes, s)
+}
+
+func (k *APIKey) expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+type APIKeyRepository interface {
+	Create(k *APIKey) error
+	ByPrefix(prefix string) (*APIKey, error)
+	ListByUser(userID string) ([]APIKey, error)
+	Update(k *APIKey) error
+	Delete(id string) error
+	DeleteByUser(userID string) error
+}
+
+type memoryAPIKeyRepo struct {
+	mu   sync.RWMutex
+	byID map[string]APIKey
+}
+
+func NewAPIKeyRepository() APIKeyRepository {
+	return &memoryAPIKeyRepo{byID: make(map[string]APIKey)}
+}
+
+func (r *memoryAPIKeyRepo) Create(k *APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID[k.ID] = cloneAPIKey(*k)
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) ByPrefix(prefix string) (*APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.byID {
+		if k.Prefix == prefix {
+			k = cloneAPIKey(k)
+			return &k, nil
+		}
+	}
+	return nil, ErrAPIKeyNotFound
+}
+
+func (r *memoryAPIKeyRepo) ListByUser(userID string) ([]APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]APIKey, 0)
+	for _, k := range r.byID {
+		if k.UserID == userID {
+			out = append(out, cloneAPIKey(k))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *memoryAPIKeyRepo) Update(k *APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.updateErr(k); err != nil {
+		return err
+	}
+	r.byID[k.ID] = cloneAPIKey(*k)
+	return nil
+}
+
+// updateErr returns the error Update fails with for k; r.mu must be held.
+func (r *memoryAPIKeyRepo) updateErr(k *APIKey) error {
+	if _, ok := r.byID[k.ID]; !ok {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.byID, id)
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) DeleteByUser(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, k := range r.byID {
+		if k.UserID == userID {
+			delete(r.byID, id)
+		}
+	}
+	return nil
+}
+
+func cloneAPIKey(k APIKey) APIKey {
+	k.Scopes = slices.Clone(k.Scopes)
+	return k
+}
+
+func (s *Service) SetAPIKeyRepository(r APIKeyRepository) {
+	s.apiKeys = r
+}
+
+// CreateAPIKey issues a key for userID with the given scopes. The returned
+// string is the key itself and cannot be recovered later.
+func (s *Service) CreateAPIKey(userID, name string, scopes []Scope, expiresAt *time.Time) (*APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrValidation)
+	}
+	if len(name) > maxAPIKeyNameLen {
+		return nil, "", fmt.Errorf("%w: name is too long", ErrValidation)
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	now := s.now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", fmt.Errorf("%w: expiresAt must be in the future", ErrValidation)
+	}
+
+	if _, err := s.repo.ByID(userID); err != nil {
+		return nil, "", err
+	}
+	existing, err := s.apiKeys.ListByUser(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(existing) >= maxAPIKeys {
+		return nil, "", fmt.Errorf("%w: at most %d API keys", ErrValidation, maxAPIKeys)
+	}
+
+	prefix, err := randomHex(apiKeyPrefixBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	k := &APIKey{
+		ID:         s.idgen(),
+		UserID:     userID,
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: hashToken(secret),
+		Scopes:     scopes,
+		CreatedAt:  now,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.apiKeys.Create(k); err != nil {
+		return nil, "", err
+	}
+	return k, apiKeyMarker + "_" + prefix + "_" + secret, nil
+}
+
+func (s *Service) APIKeys(userID string) ([]APIKey, error) {
+	return s.apiKeys.ListByUser(userID)
+}
+
+// RevokeAPIKey deletes one of userID's keys.
+func (s *Service) RevokeAPIKey(userID, id string) error {
+	list, err := s.apiKeys.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	for _, k := range list {
+		if k.ID == id {
+			return s.apiKeys.Delete(id)
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+// AuthenticateAPIKey resolves a key to its owner. Unknown, malformed and
+// expired keys get ErrUnauthorized; owners that are suspended get
+// ErrSuspended.
+func (s *Service) AuthenticateAPIKey(raw string) (*User, *APIKey, error) {
+	marker, rest, ok := strings.Cut(strings.TrimSpace(raw), "_")
+	if !ok || marker != apiKeyMarker {
+		return nil, nil, ErrUnauthorized
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return nil, nil, ErrUnauthorized
+	}
+
+	k, err := s.apiKeys.ByPrefix(prefix)
+	if err != nil {
+		return nil, nil, ErrUnauthorized
+	}
+	if subtle.ConstantTimeCompare([]byte(k.SecretHash), []byte(hashToken(secret))) != 1 {
+		return nil, nil, ErrUnauthorized
+	}
+	now := s.now()
+	if k.expired(now) {
+		return nil, nil, ErrUnauthorized
+	}
+
+	u, err := s.repo.ByID(k.UserID)
+	if err != nil {
+		return nil, nil, ErrUnauthorized
+	}
+	if u.Suspended(now) {
+		return nil, nil, ErrSuspended
+	}
+
+	// like sessions, the last use is written back at most once a minute
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchEvery {
+		k.LastUsedAt = &now
+		if err := s.apiKeys.Update(k); err != nil {
+			log.Printf("failed to record use of api key %s: %v", k.ID, err)
+		}
+	}
+	return u, k, nil
+}
+
+// normalizeScopes rejects unknown scopes and returns the rest sorted and
+// without duplicates.
+func normalizeScopes(scopes []Scope) ([]Scope, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrValidation)
+	}
+	for _, sc := range scopes {
+		if !slices.Contains(knownScopes, sc) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrValidation, sc)
+		}
+	}
+	out := slices.Clone(scopes)
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
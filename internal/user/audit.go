@@ -0,0 +1,43 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+)
+
+const maxReasonLen = 500
+
+// Audit actions recorded by the user service.
+const (
+	AuditSuspend     = "user.suspend"
+	AuditUnsuspend   = "user.unsuspend"
+	AuditImpersonate = "user.impersonate"
+)
+
+// AuditLog records administrative actions.
+type AuditLog interface {
+	Record(actorID, action, targetID string, details map[string]string) error
+}
+
+func (s *Service) SetAuditLog(l AuditLog) {
+	s.audit = l
+}
+
+func (s *Service) record(actorID, action, targetID string, details map[string]string) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.Record(actorID, action, targetID, details)
+}
+
+// normalizeReason validates the justification an admin gives for an action.
+func normalizeReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", fmt.Errorf("%w: reason is required", ErrValidation)
+	}
+	if len(reason) > maxReasonLen {
+		return "", fmt.Errorf("%w: reason is too long", ErrValidation)
+	}
+	return reason, nil
+}
@@ -0,0 +1,170 @@
+package user
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Gab-Mello/service-finder/internal/ports"
+)
+
+const (
+	maxAddresses = 20
+	maxLabelLen  = 50
+	maxStreetLen = 200
+	maxNumberLen = 20
+	maxNotesLen  = 500
+)
+
+// postalCodeRegex accepts a CEP with or without the hyphen.
+var postalCodeRegex = regexp.MustCompile(`^\d{5}-?\d{3}$`)
+
+func (s *Service) UpdateCustomerProfile(userID, phone string) (*User, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return nil, fmt.Errorf("%w: phone is required", ErrValidation)
+	}
+	if len(phone) > maxPhoneLen || !phoneRegex.MatchString(phone) {
+		return nil, fmt.Errorf("%w: invalid phone format", ErrValidation)
+	}
+
+	return s.updateCustomer(userID, func(c *CustomerProfile) error {
+		c.Phone = phone
+		return nil
+	})
+}
+
+// Addresses returns the address book of a customer, empty if they have
+// none yet.
+func (s *Service) Addresses(userID string) ([]ports.Address, error) {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Customer == nil {
+		return []ports.Address{}, nil
+	}
+	return slices.Clone(u.Customer.Addresses), nil
+}
+
+// Address implements ports.AddressBook.
+func (s *Service) Address(userID, addressID string) (ports.Address, error) {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return ports.Address{}, err
+	}
+	if u.Customer != nil {
+		if i := indexAddress(u.Customer.Addresses, addressID); i >= 0 {
+			return u.Customer.Addresses[i], nil
+		}
+	}
+	return ports.Address{}, ErrAddressNotFound
+}
+
+func (s *Service) AddAddress(userID string, a ports.Address) (ports.Address, error) {
+	a, err := normalizeAddress(a)
+	if err != nil {
+		return ports.Address{}, err
+	}
+	a.ID = s.idgen()
+
+	_, err = s.updateCustomer(userID, func(c *CustomerProfile) error {
+		if len(c.Addresses) >= maxAddresses {
+			return fmt.Errorf("%w: at most %d addresses can be saved", ErrValidation, maxAddresses)
+		}
+		c.Addresses = append(c.Addresses, a)
+		return nil
+	})
+	if err != nil {
+		return ports.Address{}, err
+	}
+	return a, nil
+}
+
+func (s *Service) UpdateAddress(userID string, a ports.Address) (ports.Address, error) {
+	a, err := normalizeAddress(a)
+	if err != nil {
+		return ports.Address{}, err
+	}
+
+	_, err = s.updateCustomer(userID, func(c *CustomerProfile) error {
+		i := indexAddress(c.Addresses, a.ID)
+		if i < 0 {
+			return ErrAddressNotFound
+		}
+		c.Addresses[i] = a
+		return nil
+	})
+	if err != nil {
+		return ports.Address{}, err
+	}
+	return a, nil
+}
+
+func (s *Service) DeleteAddress(userID, addressID string) error {
+	_, err := s.updateCustomer(userID, func(c *CustomerProfile) error {
+		i := indexAddress(c.Addresses, addressID)
+		if i < 0 {
+			return ErrAddressNotFound
+		}
+		c.Addresses = slices.Delete(c.Addresses, i, i+1)
+		return nil
+	})
+	return err
+}
+
+// updateCustomer applies fn to a copy of the customer profile, creating it
+// on first use, and saves the result. Repositories hand out shallow copies,
+// so the stored profile must never be modified in place.
+func (s *Service) updateCustomer(userID string, fn func(*CustomerProfile) error) (*User, error) {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.HasRole(RoleCustomer) {
+		return nil, ErrUnauthorized
+	}
+
+	c := CustomerProfile{Addresses: []ports.Address{}}
+	if u.Customer != nil {
+		c.Phone = u.Customer.Phone
+		c.Addresses = slices.Clone(u.Customer.Addresses)
+	}
+	if err := fn(&c); err != nil {
+		return nil, err
+	}
+
+	u.Customer = &c
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func indexAddress(list []ports.Address, id string) int {
+	return slices.IndexFunc(list, func(a ports.Address) bool { return a.ID == id })
+}
+
+func normalizeAddress(a ports.Address) (ports.Address, error) {
+	a.Label = strings.TrimSpace(a.Label)
+	a.Street = strings.TrimSpace(a.Street)
+	a.Number = strings.TrimSpace(a.Number)
+	a.District = strings.TrimSpace(a.District)
+	a.City = strings.TrimSpace(a.City)
+	a.PostalCode = strings.TrimSpace(a.PostalCode)
+	a.Notes = strings.TrimSpace(a.Notes)
+
+	if a.Label == "" || a.Street == "" || a.Number == "" || a.District == "" || a.City == "" || a.PostalCode == "" {
+		return a, fmt.Errorf("%w: label, street, number, district, city and postal code are required", ErrValidation)
+	}
+	if !postalCodeRegex.MatchString(a.PostalCode) {
+		return a, fmt.Errorf("%w: invalid postal code", ErrValidation)
+	}
+	if len(a.Label) > maxLabelLen || len(a.Street) > maxStreetLen || len(a.Number) > maxNumberLen ||
+		len(a.District) > maxDistrictLen || len(a.City) > maxCityLen || len(a.Notes) > maxNotesLen {
+		return a, fmt.Errorf("%w: address field is too long", ErrValidation)
+	}
+	return a, nil
+}
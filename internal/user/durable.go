@@ -0,0 +1,276 @@
+package user
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/durable"
+	"github.com/Gab-Mello/service-finder/internal/storage/wal"
+)
+
+// durableRepo keeps the in-memory repository authoritative for reads and
+// writes every mutation ahead to the log, so the state can be rebuilt after
+// a restart.
+type durableRepo struct {
+	*memoryRepo
+	log *durable.Repo[User]
+}
+
+func NewDurableRepository(w *wal.Log) (Repository, error) {
+	m := NewRepository().(*memoryRepo)
+	l, err := durable.NewRepo(w, "users", m, m.all, durable.Checks[User]{
+		Create: func(u *User) error {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+			return m.createErr(u)
+		},
+		Update: func(u *User) error {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+			return m.updateErr(u)
+		},
+		Delete: func(id string) error {
+			m.mu.RLock()
+			defer m.mu.RUnlock()
+			return m.deleteErr(id)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &durableRepo{memoryRepo: m, log: l}, nil
+}
+
+func (r *durableRepo) Create(u *User) error   { return r.log.Create(u) }
+func (r *durableRepo) Update(u *User) error   { return r.log.Update(u) }
+func (r *durableRepo) Delete(id string) error { return r.log.Delete(id) }
+
+func (r *memoryRepo) all() []User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]User, 0, len(r.byID))
+	for _, u := range r.byID {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+const opDeleteByUser = "delete_by_user"
+
+type tokenDeletion struct {
+	UserID  string
+	Purpose TokenPurpose
+}
+
+type durableTokenRepo struct {
+	*memoryTokenRepo
+	log *durable.Log[Token]
+}
+
+func NewDurableTokenRepository(w *wal.Log) (TokenRepository, error) {
+	m := NewTokenRepository().(*memoryTokenRepo)
+	l, err := durable.Open(w, "user tokens", m.all,
+		func(all []Token) error {
+			for i := range all {
+				if err := m.Create(&all[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(op string, dec wal.Decoder) error {
+			switch op {
+			case durable.OpCreate:
+				var t Token
+				if err := dec(&t); err != nil {
+					return err
+				}
+				return m.Create(&t)
+			case opDeleteByUser:
+				var d tokenDeletion
+				if err := dec(&d); err != nil {
+					return err
+				}
+				return m.DeleteByUser(d.UserID, d.Purpose)
+			default:
+				return fmt.Errorf("unknown operation %q", op)
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &durableTokenRepo{memoryTokenRepo: m, log: l}, nil
+}
+
+func (r *durableTokenRepo) Create(t *Token) error {
+	return r.log.Do(durable.OpCreate, t, nil, func() error { return r.memoryTokenRepo.Create(t) })
+}
+
+func (r *durableTokenRepo) DeleteByUser(userID string, purpose TokenPurpose) error {
+	return r.log.Do(opDeleteByUser, tokenDeletion{UserID: userID, Purpose: purpose}, nil, func() error {
+		return r.memoryTokenRepo.DeleteByUser(userID, purpose)
+	})
+}
+
+func (r *memoryTokenRepo) all() []Token {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Token, 0, len(r.byHash))
+	for _, t := range r.byHash {
+		out = append(out, t)
+	}
+	return out
+}
+
+type durableIdentityRepo struct {
+	*memoryIdentityRepo
+	log *durable.Log[Identity]
+}
+
+func NewDurableIdentityRepository(w *wal.Log) (IdentityRepository, error) {
+	m := NewIdentityRepository().(*memoryIdentityRepo)
+	l, err := durable.Open(w, "identities", m.all,
+		func(all []Identity) error {
+			for i := range all {
+				if err := m.Create(&all[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(op string, dec wal.Decoder) error {
+			switch op {
+			case durable.OpCreate:
+				var id Identity
+				if err := dec(&id); err != nil {
+					return err
+				}
+				return m.Create(&id)
+			case opDeleteByUser:
+				var userID string
+				if err := dec(&userID); err != nil {
+					return err
+				}
+				return m.DeleteByUser(userID)
+			default:
+				return fmt.Errorf("unknown operation %q", op)
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &durableIdentityRepo{memoryIdentityRepo: m, log: l}, nil
+}
+
+func (r *durableIdentityRepo) Create(id *Identity) error {
+	check := func() error {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return r.createErr(id)
+	}
+	return r.log.Do(durable.OpCreate, id, check, func() error { return r.memoryIdentityRepo.Create(id) })
+}
+
+func (r *durableIdentityRepo) DeleteByUser(userID string) error {
+	return r.log.Do(opDeleteByUser, userID, nil, func() error { return r.memoryIdentityRepo.DeleteByUser(userID) })
+}
+
+func (r *memoryIdentityRepo) all() []Identity {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Identity, 0, len(r.byKey))
+	for _, id := range r.byKey {
+		out = append(out, id)
+	}
+	return out
+}
+
+type durableAPIKeyRepo struct {
+	*memoryAPIKeyRepo
+	log *durable.Log[APIKey]
+}
+
+func NewDurableAPIKeyRepository(w *wal.Log) (APIKeyRepository, error) {
+	m := NewAPIKeyRepository().(*memoryAPIKeyRepo)
+	l, err := durable.Open(w, "api keys", m.all,
+		func(all []APIKey) error {
+			for i := range all {
+				if err := m.Create(&all[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(op string, dec wal.Decoder) error {
+			switch op {
+			case durable.OpCreate, durable.OpUpdate:
+				var k APIKey
+				if err := dec(&k); err != nil {
+					return err
+				}
+				return m.Create(&k)
+			case durable.OpDelete:
+				var id string
+				if err := dec(&id); err != nil {
+					return err
+				}
+				return m.Delete(id)
+			case opDeleteByUser:
+				var userID string
+				if err := dec(&userID); err != nil {
+					return err
+				}
+				return m.DeleteByUser(userID)
+			default:
+				return fmt.Errorf("unknown operation %q", op)
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &durableAPIKeyRepo{memoryAPIKeyRepo: m, log: l}, nil
+}
+
+func (r *durableAPIKeyRepo) Create(k *APIKey) error {
+	return r.log.Do(durable.OpCreate, k, nil, func() error { return r.memoryAPIKeyRepo.Create(k) })
+}
+
+func (r *durableAPIKeyRepo) Update(k *APIKey) error {
+	check := func() error {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return r.updateErr(k)
+	}
+	return r.log.Do(durable.OpUpdate, k, check, func() error { return r.memoryAPIKeyRepo.Update(k) })
+}
+
+func (r *durableAPIKeyRepo) Delete(id string) error {
+	return r.log.Do(durable.OpDelete, id, nil, func() error { return r.memoryAPIKeyRepo.Delete(id) })
+}
+
+func (r *durableAPIKeyRepo) DeleteByUser(userID string) error {
+	return r.log.Do(opDeleteByUser, userID, nil, func() error { return r.memoryAPIKeyRepo.DeleteByUser(userID) })
+}
+
+func (r *memoryAPIKeyRepo) all() []APIKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]APIKey, 0, len(r.byID))
+	for _, k := range r.byID {
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,141 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// LoginExternal signs in through an identity provider. A known identity
+// maps to its user. Otherwise the identity is linked to the account with
+// the same email, provided the provider verified that email; if the account
+// had not verified it, it is reclaimed first. If no account has that email,
+// one is created with role and no password.
+func (s *Service) LoginExternal(p ExternalProfile, role string) (*User, error) {
+	link, err := s.identities.BySubject(p.Issuer, p.Subject)
+	if err == nil {
+		u, err := s.repo.ByID(link.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if u.Suspended(s.now()) {
+			return nil, ErrSuspended
+		}
+		return u, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(p.Email))
+	if !emailRegex.MatchString(email) {
+		return nil, fmt.Errorf("%w: identity provider did not return a valid email", ErrValidation)
+	}
+
+	u, err := s.repo.ByEmail(email)
+	switch {
+	case err == nil:
+		if u.Suspended(s.now()) {
+			return nil, ErrSuspended
+		}
+		// linking on an unverified claim would let anyone who can set that
+		// address at the provider take over the account
+		if !p.EmailVerified {
+			return nil, ErrEmailTaken
+		}
+		if !u.EmailVerified {
+			if err := s.reclaim(u); err != nil {
+				return nil, err
+			}
+		}
+	case errors.Is(err, ErrNotFound):
+		if u, err = s.createExternal(p, email, role); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	err = s.identities.Create(&Identity{
+		Issuer: p.Issuer, Subject: p.Subject, UserID: u.ID, Email: email, CreatedAt: s.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// reclaim hands an account whose email was never verified to the owner of
+// that email, as proven by the identity provider. Whoever registered it
+// may not have been them, so everything they could use to get back in is
+// dropped: the password, two-factor, sessions, API keys and pending tokens.
+func (s *Service) reclaim(u *User) error {
+	u.EmailVerified = true
+	u.PasswordHash = ""
+	u.TwoFactor = TwoFactor{}
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return err
+	}
+	if s.sessions != nil {
+		if err := s.sessions.RevokeAll(u.ID); err != nil {
+			return err
+		}
+	}
+	for _, purpose := range []TokenPurpose{PurposePasswordReset, PurposeEmailVerification, PurposeLoginChallenge, PurposeEmailChange} {
+		if err := s.tokens.DeleteByUser(u.ID, purpose); err != nil {
+			return err
+		}
+	}
+	return s.apiKeys.DeleteByUser(u.ID)
+}
+
+// Identities lists the external identities linked to a user.
+func (s *Service) Identities(userID string) ([]Identity, error) {
+	return s.identities.ListByUser(userID)
+}
+
+func (s *Service) createExternal(p ExternalProfile, email, role string) (*User, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if role == "" {
+		role = string(RoleCustomer)
+	}
+	if role != string(RoleProvider) && role != string(RoleCustomer) {
+		return nil, fmt.Errorf("%w: role must be 'provider' or 'customer'", ErrValidation)
+	}
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	if len(name) > maxNameLen {
+		// cut on a character boundary, as provider names are often accented
+		n := maxNameLen
+		for n > 0 && !utf8.RuneStart(name[n]) {
+			n--
+		}
+		name = name[:n]
+	}
+
+	now := s.now()
+	u := &User{
+		ID:            s.idgen(),
+		Name:          name,
+		Email:         email,
+		Role:          Role(role),
+		Roles:         rolesFor(Role(role)),
+		EmailVerified: p.EmailVerified,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.repo.Create(u); err != nil {
+		return nil, err
+	}
+	if !u.EmailVerified {
+		if err := s.sendVerification(u); err != nil {
+			log.Printf("failed to send verification email to user %s: %v", u.ID, err)
+		}
+	}
+	return u, nil
+}
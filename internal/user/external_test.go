@@ -0,0 +1,27 @@
+package user_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+func TestLoginExternalTruncatesNameOnCharacterBoundary(t *testing.T) {
+	svc := user.NewService(user.NewRepository(), nil, nil, nil)
+	// 99 bytes of ASCII put the second byte of "ã" at the 100-byte limit
+	long := strings.Repeat("a", 99) + "ão Silva"
+	u, err := svc.LoginExternal(user.ExternalProfile{
+		Issuer: "https://idp.test", Subject: "s1", Email: "joao@example.com", EmailVerified: true, Name: long,
+	}, "")
+	if err != nil {
+		t.Fatalf("LoginExternal: %v", err)
+	}
+	if !utf8.ValidString(u.Name) {
+		t.Fatalf("name %q is not valid UTF-8", u.Name)
+	}
+	if u.Name != strings.Repeat("a", 99) {
+		t.Fatalf("name = %q, want the 99 characters before the cut", u.Name)
+	}
+}
@@ -0,0 +1,100 @@
+package user
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Identity links an account at an external OpenID Connect issuer to a
+// local user.
+type Identity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	UserID    string    `json:"-"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ExternalProfile is what an identity provider asserts about a login.
+type ExternalProfile struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type IdentityRepository interface {
+	Create(id *Identity) error
+	BySubject(issuer, subject string) (*Identity, error)
+	ListByUser(userID string) ([]Identity, error)
+	DeleteByUser(userID string) error
+}
+
+type memoryIdentityRepo struct {
+	mu    sync.RWMutex
+	byKey map[string]Identity
+}
+
+func NewIdentityRepository() IdentityRepository {
+	return &memoryIdentityRepo{byKey: make(map[string]Identity)}
+}
+
+func identityKey(issuer, subject string) string { return issuer + "\x00" + subject }
+
+func (r *memoryIdentityRepo) Create(id *Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.createErr(id); err != nil {
+		return err
+	}
+	r.byKey[identityKey(id.Issuer, id.Subject)] = *id
+	return nil
+}
+
+// createErr returns the error Create fails with for id; r.mu must be held.
+func (r *memoryIdentityRepo) createErr(id *Identity) error {
+	if _, ok := r.byKey[identityKey(id.Issuer, id.Subject)]; ok {
+		return ErrIdentityTaken
+	}
+	return nil
+}
+
+func (r *memoryIdentityRepo) BySubject(issuer, subject string) (*Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byKey[identityKey(issuer, subject)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &id, nil
+}
+
+func (r *memoryIdentityRepo) ListByUser(userID string) ([]Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Identity, 0)
+	for _, id := range r.byKey {
+		if id.UserID == userID {
+			out = append(out, id)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *memoryIdentityRepo) DeleteByUser(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, id := range r.byKey {
+		if id.UserID == userID {
+			delete(r.byKey, key)
+		}
+	}
+	return nil
+}
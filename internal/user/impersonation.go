@@ -0,0 +1,31 @@
+package user
+
+import "fmt"
+
+// Impersonate checks that adminID may act as userID and records that it
+// does. The session itself is started by the caller. Admins and suspended
+// users cannot be impersonated.
+func (s *Service) Impersonate(adminID, userID, reason string) (*User, error) {
+	reason, err := normalizeReason(reason)
+	if err != nil {
+		return nil, err
+	}
+	if adminID == userID {
+		return nil, fmt.Errorf("%w: cannot impersonate yourself", ErrValidation)
+	}
+
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.HasRole(RoleAdmin) {
+		return nil, fmt.Errorf("%w: admins cannot be impersonated", ErrValidation)
+	}
+	if u.Suspended(s.now()) {
+		return nil, ErrSuspended
+	}
+	if err := s.record(adminID, AuditImpersonate, u.ID, map[string]string{"reason": reason}); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
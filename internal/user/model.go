@@ -1,23 +1,94 @@
 package user
 
-import "time"
+import (
+	"slices"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/ports"
+)
 
 type Role string
 
 const (
 	RoleProvider Role = "provider"
 	RoleCustomer Role = "customer"
+	// RoleAdmin cannot be chosen at registration; see Service.EnsureAdmin.
+	RoleAdmin Role = "admin"
 )
 
 type User struct {
-	ID           string           `json:"id"`
-	Name         string           `json:"name"`
-	Email        string           `json:"email"`
-	PasswordHash string           `json:"-"`
-	Role         Role             `json:"role"`
-	Provider     *ProviderProfile `json:"provider,omitempty"`
-	CreatedAt    time.Time        `json:"createdAt"`
-	UpdatedAt    time.Time        `json:"updatedAt"`
+	ID                 string           `json:"id"`
+	Name               string           `json:"name"`
+	Email              string           `json:"email"`
+	PasswordHash       string           `json:"-"`
+	Role               Role             `json:"role"` // chosen at registration
+	Roles              []Role           `json:"roles"`
+	EmailVerified      bool             `json:"emailVerified"`
+	VerificationSentAt *time.Time       `json:"-"`
+	PendingEmail       string           `json:"-"` // requested new address, until confirmed
+	TwoFactor          TwoFactor        `json:"-"`
+	Provider           *ProviderProfile `json:"provider,omitempty"`
+	Customer           *CustomerProfile `json:"customer,omitempty"`
+	Suspension         *Suspension      `json:"-"`
+	ErasureAlias       string           `json:"-"` // replaces the user elsewhere once deletion started
+	CreatedAt          time.Time        `json:"createdAt"`
+	UpdatedAt          time.Time        `json:"updatedAt"`
+}
+
+// HasRole reports whether u may act as r.
+func (u *User) HasRole(r Role) bool {
+	return slices.Contains(u.Capabilities(), r)
+}
+
+// Capabilities returns every role the user holds. Accounts stored before
+// users could hold several roles only have Role and get what registering
+// with it grants.
+func (u *User) Capabilities() []Role {
+	if len(u.Roles) > 0 {
+		return u.Roles
+	}
+	return rolesFor(u.Role)
+}
+
+// rolesFor returns the capabilities of a new account registered as r. Every
+// account can hire; providers can also offer services.
+func rolesFor(r Role) []Role {
+	if r == RoleProvider {
+		return []Role{RoleCustomer, RoleProvider}
+	}
+	return []Role{r}
+}
+
+// grant adds r to the user's capabilities without touching the slice the
+// repository handed out.
+func (u *User) grant(r Role) {
+	if u.HasRole(r) {
+		return
+	}
+	u.Roles = append(slices.Clone(u.Capabilities()), r)
+}
+
+// Suspension blocks an account, until Until when set or until an admin
+// lifts it.
+type Suspension struct {
+	Reason string     `json:"reason"`
+	By     string     `json:"by"` // admin who suspended the account
+	At     time.Time  `json:"at"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// Suspended reports whether the account is blocked at now.
+func (u *User) Suspended(now time.Time) bool {
+	return u.Suspension != nil && (u.Suspension.Until == nil || now.Before(*u.Suspension.Until))
+}
+
+// TwoFactor holds the TOTP enrollment of an account. Secret is set as soon
+// as enrollment starts; Enabled only once the first code is confirmed.
+type TwoFactor struct {
+	Enabled       bool
+	Secret        string
+	LastStep      int64    // last accepted TOTP step, to block replays
+	RecoveryCodes []string // SHA-256 of the unused recovery codes
 }
 
 type ProviderProfile struct {
@@ -28,12 +99,43 @@ type ProviderProfile struct {
 	District  string `json:"district"`
 }
 
+type CustomerProfile struct {
+	Phone     string          `json:"phone,omitempty"`
+	Addresses []ports.Address `json:"addresses"`
+}
+
 var (
 	ErrEmailTaken   = errStr("email already in use")
 	ErrNotFound     = errStr("user not found")
 	ErrUnauthorized = errStr("unauthorized")
+
+	ErrInvalidToken   = errStr("invalid or expired token")
+	ErrMailerDisabled = errStr("email delivery is not configured")
+
+	ErrTooManyRequests = errStr("too many requests")
+
+	ErrIdentityTaken = errStr("external identity already linked")
+
+	ErrAddressNotFound = ports.ErrAddressNotFound
+
+	ErrAlreadyProvider = errStr("account is already a provider")
+
+	ErrSuspended = errStr("account suspended")
+
+	ErrAPIKeyNotFound = errStr("api key not found")
+
+	ErrReauthRequired = errStr("recent login required")
 )
 
+// RetryAfterError is returned by throttled operations. It matches
+// ErrTooManyRequests with errors.Is.
+type RetryAfterError struct {
+	Wait time.Duration
+}
+
+func (e *RetryAfterError) Error() string        { return ErrTooManyRequests.Error() }
+func (e *RetryAfterError) Is(target error) bool { return target == ErrTooManyRequests }
+
 type errStr string
 
 func (e errStr) Error() string { return string(e) }
@@ -0,0 +1,103 @@
+package user
+
+import "time"
+
+// PersonalData is implemented by domains that keep records about users, so
+// data subject requests cover them.
+type PersonalData interface {
+	// ExportUser returns the records about userID for a data export.
+	ExportUser(userID string) (any, error)
+	// AnonymizeUser replaces userID by alias and drops what identifies the
+	// person, keeping what the other party of each record relies on.
+	AnonymizeUser(userID, alias string) error
+}
+
+type personalDataSource struct {
+	section string
+	data    PersonalData
+}
+
+// AddPersonalData includes p in data exports under section and anonymizes
+// it when an account is deleted. Sources are anonymized in the order they
+// were added.
+func (s *Service) AddPersonalData(section string, p PersonalData) {
+	s.personalData = append(s.personalData, personalDataSource{section: section, data: p})
+}
+
+// AccountExport is everything the account itself stores about a user,
+// secrets excepted.
+type AccountExport struct {
+	ID               string           `json:"id"`
+	Name             string           `json:"name"`
+	Email            string           `json:"email"`
+	PendingEmail     string           `json:"pendingEmail,omitempty"`
+	Role             Role             `json:"role"`
+	Roles            []Role           `json:"roles"`
+	EmailVerified    bool             `json:"emailVerified"`
+	TwoFactorEnabled bool             `json:"twoFactorEnabled"`
+	Provider         *ProviderProfile `json:"provider,omitempty"`
+	Customer         *CustomerProfile `json:"customer,omitempty"`
+	Identities       []Identity       `json:"identities"`
+	CreatedAt        time.Time        `json:"createdAt"`
+	UpdatedAt        time.Time        `json:"updatedAt"`
+}
+
+// ExportData collects the personal data held about a user: the account
+// under "account" and one entry per source added with AddPersonalData.
+func (s *Service) ExportData(userID string) (map[string]any, error) {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := s.identities.ListByUser(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]any{
+		"exportedAt": s.now().UTC(),
+		"account": AccountExport{
+			ID:               u.ID,
+			Name:             u.Name,
+			Email:            u.Email,
+			PendingEmail:     u.PendingEmail,
+			Role:             u.Role,
+			Roles:            u.Capabilities(),
+			EmailVerified:    u.EmailVerified,
+			TwoFactorEnabled: u.TwoFactor.Enabled,
+			Provider:         u.Provider,
+			Customer:         u.Customer,
+			Identities:       identities,
+			CreatedAt:        u.CreatedAt,
+			UpdatedAt:        u.UpdatedAt,
+		},
+	}
+	for _, src := range s.personalData {
+		data, err := src.data.ExportUser(u.ID)
+		if err != nil {
+			return nil, err
+		}
+		out[src.section] = data
+	}
+	return out, nil
+}
+
+// erasePersonalData anonymizes the user in every source under one alias
+// unrelated to the account ID. The alias is stored on the account before
+// any source is touched, so retrying after a failed source reuses it and
+// all the user's records keep sharing one pseudonym.
+func (s *Service) erasePersonalData(u *User) error {
+	if u.ErasureAlias == "" {
+		u.ErasureAlias = "deleted-" + s.idgen()
+		u.UpdatedAt = s.now()
+		if err := s.repo.Update(u); err != nil {
+			return err
+		}
+	}
+	for _, src := range s.personalData {
+		if err := src.data.AnonymizeUser(u.ID, u.ErasureAlias); err != nil {
+			return err
+		}
+	}
+	return nil
+}
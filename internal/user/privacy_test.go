@@ -0,0 +1,72 @@
+package user_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+// recordingSource remembers the alias each user was anonymized under and
+// fails while err is set.
+type recordingSource struct {
+	err     error
+	aliases []string
+}
+
+func (s *recordingSource) ExportUser(string) (any, error) { return nil, nil }
+
+func (s *recordingSource) AnonymizeUser(_, alias string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.aliases = append(s.aliases, alias)
+	return nil
+}
+
+type revoker struct{ revoked []string }
+
+func (r *revoker) RevokeAll(userID string) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+func TestDeleteAccountRetryKeepsAlias(t *testing.T) {
+	svc := user.NewService(user.NewRepository(), nil, nil, nil)
+	sessions := &revoker{}
+	svc.SetSessionRevoker(sessions)
+	orders, reviews := &recordingSource{}, &recordingSource{err: errors.New("reviews unavailable")}
+	svc.AddPersonalData("orders", orders)
+	svc.AddPersonalData("reviews", reviews)
+
+	u, err := svc.Register("Ana", "ana@example.com", "secret123", "customer")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := svc.DeleteAccount(u.ID, user.Reauth{Password: "secret123"}); !errors.Is(err, reviews.err) {
+		t.Fatalf("DeleteAccount = %v, want the source error", err)
+	}
+	if len(sessions.revoked) != 1 {
+		t.Fatal("sessions outlived a failed deletion")
+	}
+	if _, err := svc.ByID(u.ID); err != nil {
+		t.Fatalf("account gone after a failed deletion: %v", err)
+	}
+
+	reviews.err = nil
+	if err := svc.DeleteAccount(u.ID, user.Reauth{Password: "secret123"}); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	if _, err := svc.ByID(u.ID); !errors.Is(err, user.ErrNotFound) {
+		t.Fatalf("ByID = %v, want ErrNotFound", err)
+	}
+	all := append(orders.aliases, reviews.aliases...)
+	if len(all) != 3 {
+		t.Fatalf("anonymized %d times, want 3", len(all))
+	}
+	for _, alias := range all {
+		if alias != all[0] || alias == u.ID {
+			t.Fatalf("aliases %v, want one pseudonym unrelated to %s", all, u.ID)
+		}
+	}
+}
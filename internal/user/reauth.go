@@ -0,0 +1,81 @@
+package user
+
+import "time"
+
+// reauthWindow is how long after logging in a session of an account
+// without a password may make sensitive changes without a code.
+const reauthWindow = 5 * time.Minute
+
+// Reauth is what a user offers to confirm a sensitive change such as
+// deleting the account. Accounts with a password must give it. Accounts
+// created through an identity provider have none, so they give a
+// two-factor code instead, or make the change from a session that logged
+// in within the last few minutes.
+type Reauth struct {
+	Password string
+	// Code is a current TOTP code or an unused recovery code.
+	Code string
+	// LoginAt is when the session making the request logged in; zero when
+	// unknown.
+	LoginAt time.Time
+	// IP is the client address, counted by the login lockout.
+	IP string
+}
+
+// confirmIdentity checks proof before a sensitive change. Wrong passwords
+// and codes count as failed logins, so they lock the account out just as
+// at login. Accounts without a password that cannot prove a recent login
+// get ErrReauthRequired.
+func (s *Service) confirmIdentity(u *User, proof Reauth) error {
+	if u.PasswordHash != "" {
+		if err := s.checkLockout(u.Email, proof.IP); err != nil {
+			return err
+		}
+		if !s.pw.Compare(u.PasswordHash, proof.Password) {
+			if _, err := s.failLogin(u.Email, proof.IP); err != nil {
+				return err
+			}
+			return ErrUnauthorized
+		}
+		if !u.TwoFactor.Enabled {
+			return s.succeedLogin(u.Email)
+		}
+		return nil
+	}
+
+	if proof.Code != "" && u.TwoFactor.Enabled {
+		return s.confirmSecondFactor(u, proof)
+	}
+
+	if !proof.LoginAt.IsZero() && s.now().Sub(proof.LoginAt) <= reauthWindow {
+		return nil
+	}
+	return ErrReauthRequired
+}
+
+// confirmSecondFactor checks proof.Code against the stored account rather
+// than u, which the caller may have read before a parallel request used
+// the same code, and copies the result onto u.
+func (s *Service) confirmSecondFactor(u *User, proof Reauth) error {
+	s.twoFactorMu.Lock()
+	defer s.twoFactorMu.Unlock()
+
+	if err := s.checkLockout(u.Email, proof.IP); err != nil {
+		return err
+	}
+	stored, err := s.repo.ByID(u.ID)
+	if err != nil {
+		return err
+	}
+	if !s.useSecondFactor(stored, proof.Code) {
+		if _, err := s.failLogin(u.Email, proof.IP); err != nil {
+			return err
+		}
+		return ErrUnauthorized
+	}
+	if err := s.repo.Update(stored); err != nil {
+		return err
+	}
+	u.TwoFactor = stored.TwoFactor
+	return s.succeedLogin(u.Email)
+}
@@ -0,0 +1,138 @@
+package user_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+func TestReauth(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	// passwordless returns a service and an account created through an
+	// identity provider, with its TOTP secret and recovery codes when
+	// twoFactor is set.
+	passwordless := func(t *testing.T, twoFactor bool) (*user.Service, string, string, []string) {
+		t.Helper()
+		svc := user.NewService(user.NewRepository(), nil, clock, nil)
+		svc.SetLoginThrottle(auth.NewLoginGuard(nil, auth.DefaultLockoutOptions()))
+		u, err := svc.LoginExternal(user.ExternalProfile{
+			Issuer: "https://idp.test", Subject: "s1", Email: "bia@example.com", EmailVerified: true,
+		}, "")
+		if err != nil {
+			t.Fatalf("LoginExternal: %v", err)
+		}
+		if !twoFactor {
+			return svc, u.ID, "", nil
+		}
+		secret, _, err := svc.EnrollTwoFactor(u.ID)
+		if err != nil {
+			t.Fatalf("EnrollTwoFactor: %v", err)
+		}
+		code, _ := auth.TOTPCode(secret, now)
+		recovery, err := svc.ConfirmTwoFactor(u.ID, code)
+		if err != nil {
+			t.Fatalf("ConfirmTwoFactor: %v", err)
+		}
+		return svc, u.ID, secret, recovery
+	}
+
+	t.Run("PasswordRequired", func(t *testing.T) {
+		svc := user.NewService(user.NewRepository(), nil, clock, nil)
+		u, err := svc.Register("Ana", "ana@example.com", "secret123", "customer")
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		// a fresh login does not stand in for a password the account has
+		err = svc.ChangePassword(u.ID, user.Reauth{Password: "wrong", LoginAt: now}, "newsecret123")
+		if !errors.Is(err, user.ErrUnauthorized) {
+			t.Fatalf("ChangePassword = %v, want ErrUnauthorized", err)
+		}
+		if err := svc.ChangePassword(u.ID, user.Reauth{Password: "secret123"}, "newsecret123"); err != nil {
+			t.Fatalf("ChangePassword: %v", err)
+		}
+	})
+
+	t.Run("TooManyWrongPasswords", func(t *testing.T) {
+		svc := user.NewService(user.NewRepository(), nil, clock, nil)
+		svc.SetLoginThrottle(auth.NewLoginGuard(nil, auth.DefaultLockoutOptions()))
+		u, err := svc.Register("Ana", "ana@example.com", "secret123", "customer")
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		for range 5 {
+			if err := svc.DeleteAccount(u.ID, user.Reauth{Password: "wrong"}); !errors.Is(err, user.ErrUnauthorized) {
+				t.Fatalf("DeleteAccount = %v, want ErrUnauthorized", err)
+			}
+		}
+		var retry *user.RetryAfterError
+		if err := svc.DeleteAccount(u.ID, user.Reauth{Password: "secret123"}); !errors.As(err, &retry) {
+			t.Fatalf("DeleteAccount = %v, want *RetryAfterError", err)
+		}
+		if _, err := svc.Authenticate("ana@example.com", "secret123", ""); !errors.As(err, &retry) {
+			t.Fatalf("Authenticate = %v, want *RetryAfterError", err)
+		}
+	})
+
+	t.Run("NoProof", func(t *testing.T) {
+		svc, id, _, _ := passwordless(t, false)
+		if err := svc.ChangePassword(id, user.Reauth{}, "newsecret123"); !errors.Is(err, user.ErrReauthRequired) {
+			t.Fatalf("ChangePassword = %v, want ErrReauthRequired", err)
+		}
+	})
+
+	t.Run("StaleLogin", func(t *testing.T) {
+		svc, id, _, _ := passwordless(t, false)
+		proof := user.Reauth{LoginAt: now.Add(-6 * time.Minute)}
+		if err := svc.ChangePassword(id, proof, "newsecret123"); !errors.Is(err, user.ErrReauthRequired) {
+			t.Fatalf("ChangePassword = %v, want ErrReauthRequired", err)
+		}
+	})
+
+	t.Run("RecentLogin", func(t *testing.T) {
+		svc, id, _, _ := passwordless(t, false)
+		if err := svc.ChangePassword(id, user.Reauth{LoginAt: now.Add(-time.Minute)}, "newsecret123"); err != nil {
+			t.Fatalf("ChangePassword: %v", err)
+		}
+	})
+
+	t.Run("TOTPCode", func(t *testing.T) {
+		svc, id, secret, _ := passwordless(t, true)
+		if err := svc.DisableTwoFactor(id, user.Reauth{Code: "not-a-code"}); !errors.Is(err, user.ErrUnauthorized) {
+			t.Fatalf("DisableTwoFactor = %v, want ErrUnauthorized", err)
+		}
+		now = now.Add(30 * time.Second)
+		defer func() { now = now.Add(-30 * time.Second) }()
+		code, _ := auth.TOTPCode(secret, now)
+		if err := svc.DisableTwoFactor(id, user.Reauth{Code: code}); err != nil {
+			t.Fatalf("DisableTwoFactor: %v", err)
+		}
+	})
+
+	t.Run("RecoveryCode", func(t *testing.T) {
+		svc, id, _, recovery := passwordless(t, true)
+		if err := svc.DisableTwoFactor(id, user.Reauth{Code: recovery[0]}); err != nil {
+			t.Fatalf("DisableTwoFactor: %v", err)
+		}
+	})
+
+	t.Run("TooManyWrongCodes", func(t *testing.T) {
+		svc, id, secret, _ := passwordless(t, true)
+		for range 5 {
+			if err := svc.DisableTwoFactor(id, user.Reauth{Code: "not-a-code"}); !errors.Is(err, user.ErrUnauthorized) {
+				t.Fatalf("DisableTwoFactor = %v, want ErrUnauthorized", err)
+			}
+		}
+		now = now.Add(30 * time.Second)
+		defer func() { now = now.Add(-30 * time.Second) }()
+		code, _ := auth.TOTPCode(secret, now)
+		var retry *user.RetryAfterError
+		if err := svc.DisableTwoFactor(id, user.Reauth{Code: code}); !errors.As(err, &retry) {
+			t.Fatalf("DisableTwoFactor = %v, want *RetryAfterError", err)
+		}
+	})
+}
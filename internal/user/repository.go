@@ -7,6 +7,7 @@ type Repository interface {
 	ByEmail(email string) (*User, error)
 	ByID(id string) (*User, error)
 	Update(u *User) error
+	Delete(id string) error
 }
 
 type memoryRepo struct {
@@ -26,8 +27,8 @@ func (r *memoryRepo) Create(u *User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.byEmail[u.Email]; exists {
-		return ErrEmailTaken
+	if err := r.createErr(u); err != nil {
+		return err
 	}
 
 	r.byID[u.ID] = *u
@@ -62,19 +63,58 @@ func (r *memoryRepo) Update(u *User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if err := r.updateErr(u); err != nil {
+		return err
+	}
+
+	if old := r.byID[u.ID]; old.Email != u.Email {
+		delete(r.byEmail, old.Email)
+		r.byEmail[u.Email] = u.ID
+	}
+
+	r.byID[u.ID] = *u
+	return nil
+}
+
+func (r *memoryRepo) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.deleteErr(id); err != nil {
+		return err
+	}
+	u := r.byID[id]
+	delete(r.byEmail, u.Email)
+	delete(r.byID, id)
+	return nil
+}
+
+// createErr returns the error Create fails with for u; r.mu must be held.
+func (r *memoryRepo) createErr(u *User) error {
+	if _, exists := r.byEmail[u.Email]; exists {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// updateErr returns the error Update fails with for u; r.mu must be held.
+func (r *memoryRepo) updateErr(u *User) error {
 	old, ok := r.byID[u.ID]
 	if !ok {
 		return ErrNotFound
 	}
-
 	if old.Email != u.Email {
 		if _, exists := r.byEmail[u.Email]; exists {
 			return ErrEmailTaken
 		}
-		delete(r.byEmail, old.Email)
-		r.byEmail[u.Email] = u.ID
 	}
+	return nil
+}
 
-	r.byID[u.ID] = *u
+// deleteErr returns the error Delete fails with for id; r.mu must be held.
+func (r *memoryRepo) deleteErr(id string) error {
+	if _, ok := r.byID[id]; !ok {
+		return ErrNotFound
+	}
 	return nil
 }
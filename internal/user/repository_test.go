@@ -0,0 +1,27 @@
+package user_test
+
+import (
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/storage/wal/waltest"
+	"github.com/Gab-Mello/service-finder/internal/user"
+	"github.com/Gab-Mello/service-finder/internal/user/usertest"
+)
+
+func TestRepository(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		usertest.TestRepository(t, user.NewRepository)
+	})
+	t.Run("Durable", func(t *testing.T) {
+		usertest.TestRepository(t, waltest.Repository(t, "users", user.NewDurableRepository))
+	})
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		usertest.TestAPIKeyRepository(t, user.NewAPIKeyRepository)
+	})
+	t.Run("Durable", func(t *testing.T) {
+		usertest.TestAPIKeyRepository(t, waltest.Repository(t, "api_keys", user.NewDurableAPIKeyRepository))
+	})
+}
@@ -1,12 +1,19 @@
 package user
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Gab-Mello/service-finder/internal/ports"
 	"github.com/google/uuid"
 )
 
@@ -17,6 +24,10 @@ var (
 )
 
 const (
+	passwordResetTTL      = time.Hour
+	emailVerificationTTL  = 48 * time.Hour
+	verificationResendGap = time.Minute
+
 	minPasswordLen = 8
 	maxNameLen     = 100
 	maxBioLen      = 1000
@@ -30,11 +41,47 @@ type PasswordHasher interface {
 	Compare(hash, plain string) bool
 }
 
+// PasswordRehasher is implemented by hashers that can tell when a stored hash
+// is outdated; Authenticate then replaces it after a successful login.
+type PasswordRehasher interface {
+	NeedsRehash(hash string) bool
+}
+
+// SessionRevoker ends every session of a user; it is called whenever the
+// user's password changes.
+type SessionRevoker interface {
+	RevokeAll(userID string) error
+}
+
+// LoginThrottle tracks failed logins per account and client address. Check
+// and Fail report how long logins are locked out.
+type LoginThrottle interface {
+	Check(email, ip string) (time.Duration, error)
+	Fail(email, ip string) (time.Duration, error)
+	Succeed(email string) error
+	Unlock(email string) error
+}
+
 type Service struct {
-	repo  Repository
-	pw    PasswordHasher
-	now   func() time.Time
-	idgen func() string
+	repo     Repository
+	pw       PasswordHasher
+	now      func() time.Time
+	idgen    func() string
+	sessions SessionRevoker
+	throttle LoginThrottle
+	// twoFactorMu serializes checking a code with recording its use, so
+	// parallel requests cannot replay a TOTP step or a recovery code.
+	twoFactorMu sync.Mutex
+
+	tokens TokenRepository
+	mailer ports.Mailer
+	appURL string
+
+	identities   IdentityRepository
+	apiKeys      APIKeyRepository
+	postings     ProviderPostings
+	personalData []personalDataSource
+	audit        AuditLog
 }
 
 func NewService(repo Repository, hasher PasswordHasher, now func() time.Time, idgen func() string) *Service {
@@ -47,7 +94,28 @@ func NewService(repo Repository, hasher PasswordHasher, now func() time.Time, id
 	if hasher == nil {
 		hasher = noOpHasher{}
 	}
-	return &Service{repo: repo, pw: hasher, now: now, idgen: idgen}
+	return &Service{repo: repo, pw: hasher, now: now, idgen: idgen,
+		tokens: NewTokenRepository(), identities: NewIdentityRepository(), apiKeys: NewAPIKeyRepository()}
+}
+
+func (s *Service) SetSessionRevoker(r SessionRevoker) {
+	s.sessions = r
+}
+
+func (s *Service) SetIdentityRepository(r IdentityRepository) {
+	s.identities = r
+}
+
+func (s *Service) SetLoginThrottle(t LoginThrottle) {
+	s.throttle = t
+}
+
+// SetMailer enables the flows that mail single-use links to the user.
+// appURL is the frontend base URL those links point to.
+func (s *Service) SetMailer(m ports.Mailer, tokens TokenRepository, appURL string) {
+	s.mailer = m
+	s.tokens = tokens
+	s.appURL = strings.TrimRight(appURL, "/")
 }
 
 func (s *Service) Register(name, email, password, role string) (*User, error) {
@@ -86,33 +154,395 @@ func (s *Service) Register(name, email, password, role string) (*User, error) {
 		Email:        email,
 		PasswordHash: hash,
 		Role:         Role(role),
+		Roles:        rolesFor(Role(role)),
 		CreatedAt:    s.now(),
 		UpdatedAt:    s.now(),
 	}
 	if err := s.repo.Create(u); err != nil {
 		return nil, err
 	}
+	if err := s.sendVerification(u); err != nil {
+		log.Printf("failed to send verification email to user %s: %v", u.ID, err)
+	}
+	return u, nil
+}
+
+// EnsureAdmin makes sure an admin account exists for email, promoting an
+// existing account or creating one with password. It runs at startup since
+// admins cannot register through the API.
+func (s *Service) EnsureAdmin(email, password string) (*User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if u, err := s.repo.ByEmail(email); err == nil {
+		if u.HasRole(RoleAdmin) {
+			return u, nil
+		}
+		u.grant(RoleAdmin)
+		u.UpdatedAt = s.now()
+		return u, s.repo.Update(u)
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	if !emailRegex.MatchString(email) {
+		return nil, fmt.Errorf("%w: invalid email format", ErrValidation)
+	}
+	if len(password) < minPasswordLen {
+		return nil, fmt.Errorf("%w: password must be at least %d characters", ErrValidation, minPasswordLen)
+	}
+	hash, err := s.pw.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+	u := &User{
+		ID:            s.idgen(),
+		Name:          "Admin",
+		Email:         email,
+		PasswordHash:  hash,
+		Role:          RoleAdmin,
+		Roles:         []Role{RoleAdmin},
+		EmailVerified: true,
+		CreatedAt:     s.now(),
+		UpdatedAt:     s.now(),
+	}
+	return u, s.repo.Create(u)
+}
+
+func (s *Service) VerifyEmail(token string) (*User, error) {
+	if s.mailer == nil {
+		return nil, ErrMailerDisabled
+	}
+	t, err := s.consumeToken(token, PurposeEmailVerification)
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.repo.ByID(t.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if u.EmailVerified {
+		return u, nil
+	}
+	u.EmailVerified = true
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
 	return u, nil
 }
 
-func (s *Service) Authenticate(email, password string) (*User, error) {
+// ResendVerification mails a fresh verification link, at most once per
+// verificationResendGap.
+func (s *Service) ResendVerification(userID string) error {
+	if s.mailer == nil {
+		return ErrMailerDisabled
+	}
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerified {
+		return fmt.Errorf("%w: email already verified", ErrValidation)
+	}
+	if u.VerificationSentAt != nil {
+		if wait := u.VerificationSentAt.Add(verificationResendGap).Sub(s.now()); wait > 0 {
+			return &RetryAfterError{Wait: wait}
+		}
+	}
+	return s.sendVerification(u)
+}
+
+// EmailVerified reports whether the user confirmed their address; it
+// implements ports.ProviderDirectory.
+func (s *Service) EmailVerified(id string) (bool, error) {
+	u, err := s.repo.ByID(id)
+	if err != nil {
+		return false, err
+	}
+	return u.EmailVerified, nil
+}
+
+func (s *Service) sendVerification(u *User) error {
+	if s.mailer == nil {
+		return nil
+	}
+	token, err := s.issueToken(u.ID, PurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	u.VerificationSentAt = &now
+	if err := s.repo.Update(u); err != nil {
+		return err
+	}
+	return s.mailer.Send(ports.Mail{
+		To:      u.Email,
+		Subject: "Confirme seu e-mail",
+		Body: fmt.Sprintf("Olá, %s.\n\nConfirme seu endereço de e-mail acessando o link abaixo:\n\n%s/verify-email?token=%s\n\nO link expira em %d horas.\n",
+			u.Name, s.appURL, url.QueryEscape(token), int(emailVerificationTTL.Hours())),
+	})
+}
+
+// Authenticate checks the credentials of a login made from ip. Once an
+// account or address has failed too often it returns a *RetryAfterError
+// without looking at the password. For accounts with two-factor enabled
+// the failures are only cleared once CompleteLoginChallenge accepts a code.
+func (s *Service) Authenticate(email, password, ip string) (*User, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
+	if err := s.checkLockout(email, ip); err != nil {
+		return nil, err
+	}
+
 	u, err := s.repo.ByEmail(email)
-	if err != nil || !s.pw.Compare(u.PasswordHash, password) {
+	// accounts created through an identity provider have no password
+	if err != nil || u.PasswordHash == "" || !s.pw.Compare(u.PasswordHash, password) {
+		if _, err := s.failLogin(email, ip); err != nil {
+			return nil, err
+		}
 		return nil, ErrUnauthorized
 	}
+
+	if !u.TwoFactor.Enabled {
+		if err := s.succeedLogin(email); err != nil {
+			return nil, err
+		}
+	}
+	if u.Suspended(s.now()) {
+		return nil, ErrSuspended
+	}
+	s.rehashIfNeeded(u, password)
 	return u, nil
 }
 
+// checkLockout returns a *RetryAfterError while the account or the address
+// is locked out.
+func (s *Service) checkLockout(email, ip string) error {
+	if s.throttle == nil {
+		return nil
+	}
+	wait, err := s.throttle.Check(email, ip)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		return &RetryAfterError{Wait: wait}
+	}
+	return nil
+}
+
+// failLogin counts a wrong password or code and returns the lockout it
+// caused, if any.
+func (s *Service) failLogin(email, ip string) (time.Duration, error) {
+	if s.throttle == nil {
+		return 0, nil
+	}
+	return s.throttle.Fail(email, ip)
+}
+
+func (s *Service) succeedLogin(email string) error {
+	if s.throttle == nil {
+		return nil
+	}
+	return s.throttle.Succeed(email)
+}
+
+// UnlockLogin lifts a login lockout of the account before it expires.
+func (s *Service) UnlockLogin(userID string) error {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return err
+	}
+	if s.throttle == nil {
+		return nil
+	}
+	return s.throttle.Unlock(u.Email)
+}
+
+func (s *Service) rehashIfNeeded(u *User, password string) {
+	rh, ok := s.pw.(PasswordRehasher)
+	if !ok || !rh.NeedsRehash(u.PasswordHash) {
+		return
+	}
+	hash, err := s.pw.Hash(password)
+	if err != nil {
+		log.Printf("failed to rehash password for user %s: %v", u.ID, err)
+		return
+	}
+	u.PasswordHash = hash
+	if err := s.repo.Update(u); err != nil {
+		log.Printf("failed to store rehashed password for user %s: %v", u.ID, err)
+	}
+}
+
+// ChangePassword sets a new password. Accounts without one, created
+// through an identity provider, confirm with a two-factor code or a recent
+// login instead of the current password.
+func (s *Service) ChangePassword(userID string, proof Reauth, next string) error {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmIdentity(u, proof); err != nil {
+		return err
+	}
+	return s.setPassword(u, next)
+}
+
+// RequestPasswordReset mails a reset link if email belongs to an account.
+// It reports success either way so the endpoint cannot be used to probe for
+// registered addresses.
+func (s *Service) RequestPasswordReset(email string) error {
+	if s.mailer == nil {
+		return ErrMailerDisabled
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	u, err := s.repo.ByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	token, err := s.issueToken(u.ID, PurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ports.Mail{
+		To:      u.Email,
+		Subject: "Redefinição de senha",
+		Body: fmt.Sprintf("Olá, %s.\n\nPara escolher uma nova senha, acesse o link abaixo em até %d minutos:\n\n%s/reset-password?token=%s\n\nSe você não pediu a redefinição, ignore este e-mail.\n",
+			u.Name, int(passwordResetTTL.Minutes()), s.appURL, url.QueryEscape(token)),
+	})
+}
+
+func (s *Service) ResetPassword(token, password string) error {
+	if s.mailer == nil {
+		return ErrMailerDisabled
+	}
+	t, err := s.consumeToken(token, PurposePasswordReset)
+	if err != nil {
+		return err
+	}
+	u, err := s.repo.ByID(t.UserID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if err := s.setPassword(u, password); err != nil {
+		return err
+	}
+	// proving control of the mailbox also lifts a login lockout
+	if s.throttle != nil {
+		if err := s.throttle.Unlock(u.Email); err != nil {
+			log.Printf("failed to unlock login for user %s: %v", u.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) issueToken(userID string, purpose TokenPurpose, ttl time.Duration) (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	raw := hex.EncodeToString(b[:])
+
+	now := s.now()
+	err := s.tokens.Create(&Token{
+		Hash: hashToken(raw), UserID: userID, Purpose: purpose, ExpiresAt: now.Add(ttl), CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// consumeToken validates a mailed token and invalidates every outstanding
+// token of the same purpose for its user, making it single-use.
+func (s *Service) consumeToken(raw string, purpose TokenPurpose) (*Token, error) {
+	t, err := s.tokens.ByHash(hashToken(raw))
+	if err != nil || t.Purpose != purpose || s.now().After(t.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	if err := s.tokens.DeleteByUser(t.UserID, purpose); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// setPassword stores a new password and logs the user out everywhere.
+func (s *Service) setPassword(u *User, password string) error {
+	if len(password) < minPasswordLen {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrValidation, minPasswordLen)
+	}
+	hash, err := s.pw.Hash(password)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = hash
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return err
+	}
+	if s.sessions != nil {
+		if err := s.sessions.RevokeAll(u.ID); err != nil {
+			log.Printf("failed to revoke sessions for user %s: %v", u.ID, err)
+		}
+	}
+	return nil
+}
+
 func (s *Service) UpdateProviderProfile(userID string, p ProviderProfile) (*User, error) {
 	u, err := s.repo.ByID(userID)
 	if err != nil {
 		return nil, err
 	}
-	if u.Role != RoleProvider {
+	if !u.HasRole(RoleProvider) {
+		return nil, ErrUnauthorized
+	}
+	profile, err := normalizeProviderProfile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Provider = &profile
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// BecomeProvider onboards a customer as a provider: the account gains the
+// provider role together with its profile, keeping everything else.
+func (s *Service) BecomeProvider(userID string, p ProviderProfile) (*User, error) {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.HasRole(RoleProvider) {
+		return nil, ErrAlreadyProvider
+	}
+	if !u.HasRole(RoleCustomer) {
 		return nil, ErrUnauthorized
 	}
+	profile, err := normalizeProviderProfile(p)
+	if err != nil {
+		return nil, err
+	}
 
+	u.grant(RoleProvider)
+	u.Provider = &profile
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func normalizeProviderProfile(p ProviderProfile) (ProviderProfile, error) {
 	phone := strings.TrimSpace(p.Phone)
 	city := strings.TrimSpace(p.City)
 	district := strings.TrimSpace(p.District)
@@ -120,30 +550,25 @@ func (s *Service) UpdateProviderProfile(userID string, p ProviderProfile) (*User
 	expertise := strings.TrimSpace(p.Expertise)
 
 	if phone == "" || city == "" || district == "" {
-		return nil, fmt.Errorf("%w: phone, city and district are required", ErrValidation)
+		return ProviderProfile{}, fmt.Errorf("%w: phone, city and district are required", ErrValidation)
 	}
 	if !phoneRegex.MatchString(phone) {
-		return nil, fmt.Errorf("%w: invalid phone format", ErrValidation)
+		return ProviderProfile{}, fmt.Errorf("%w: invalid phone format", ErrValidation)
 	}
 	if len(bio) > maxBioLen {
-		return nil, fmt.Errorf("%w: bio is too long", ErrValidation)
+		return ProviderProfile{}, fmt.Errorf("%w: bio is too long", ErrValidation)
 	}
 	if len(city) > maxCityLen || len(district) > maxDistrictLen {
-		return nil, fmt.Errorf("%w: city or district is too long", ErrValidation)
+		return ProviderProfile{}, fmt.Errorf("%w: city or district is too long", ErrValidation)
 	}
 
-	u.Provider = &ProviderProfile{
+	return ProviderProfile{
 		Bio:       bio,
 		Phone:     phone,
 		Expertise: expertise,
 		City:      city,
 		District:  district,
-	}
-	u.UpdatedAt = s.now()
-	if err := s.repo.Update(u); err != nil {
-		return nil, err
-	}
-	return u, nil
+	}, nil
 }
 
 func (s *Service) GetNameByID(id string) (string, error) {
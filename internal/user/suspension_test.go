@@ -0,0 +1,44 @@
+package user_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+type failingAudit struct{ err error }
+
+func (a failingAudit) Record(string, string, string, map[string]string) error { return a.err }
+
+func TestSuspensionNeedsAuditEntry(t *testing.T) {
+	svc := user.NewService(user.NewRepository(), nil, nil, nil)
+	u, err := svc.Register("Ana", "ana@example.com", "secret123", "customer")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := svc.Suspend("admin", u.ID, "spam", nil); err != nil {
+		t.Fatalf("Suspend: %v", err)
+	}
+
+	down := errors.New("audit log unavailable")
+	svc.SetAuditLog(failingAudit{down})
+	if _, err := svc.Unsuspend("admin", u.ID); !errors.Is(err, down) {
+		t.Fatalf("Unsuspend = %v, want the audit error", err)
+	}
+	if ok, _ := svc.Suspended(u.ID); !ok {
+		t.Fatal("suspension lifted without an audit entry")
+	}
+
+	svc.SetAuditLog(nil)
+	if _, err := svc.Unsuspend("admin", u.ID); err != nil {
+		t.Fatalf("Unsuspend: %v", err)
+	}
+	svc.SetAuditLog(failingAudit{down})
+	if _, err := svc.Suspend("admin", u.ID, "spam", nil); !errors.Is(err, down) {
+		t.Fatalf("Suspend = %v, want the audit error", err)
+	}
+	if ok, _ := svc.Suspended(u.ID); ok {
+		t.Fatal("suspended without an audit entry")
+	}
+}
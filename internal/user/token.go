@@ -0,0 +1,70 @@
+package user
+
+import (
+	"sync"
+	"time"
+)
+
+type TokenPurpose string
+
+const (
+	PurposePasswordReset     TokenPurpose = "password_reset"
+	PurposeEmailVerification TokenPurpose = "email_verification"
+	PurposeLoginChallenge    TokenPurpose = "login_challenge"
+	PurposeEmailChange       TokenPurpose = "email_change"
+)
+
+// Token is a single-use secret mailed to the user. Only its SHA-256 is
+// stored.
+type Token struct {
+	Hash      string
+	UserID    string
+	Purpose   TokenPurpose
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type TokenRepository interface {
+	Create(t *Token) error
+	ByHash(hash string) (*Token, error)
+	DeleteByUser(userID string, purpose TokenPurpose) error
+}
+
+type memoryTokenRepo struct {
+	mu     sync.RWMutex
+	byHash map[string]Token
+}
+
+func NewTokenRepository() TokenRepository {
+	return &memoryTokenRepo{byHash: make(map[string]Token)}
+}
+
+func (r *memoryTokenRepo) Create(t *Token) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byHash[t.Hash] = *t
+	return nil
+}
+
+func (r *memoryTokenRepo) ByHash(hash string) (*Token, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.byHash[hash]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return &t, nil
+}
+
+func (r *memoryTokenRepo) DeleteByUser(userID string, purpose TokenPurpose) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for h, t := range r.byHash {
+		if t.UserID == userID && t.Purpose == purpose {
+			delete(r.byHash, h)
+		}
+	}
+	return nil
+}
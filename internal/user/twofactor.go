@@ -0,0 +1,175 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+)
+
+const (
+	totpIssuer        = "Service Finder"
+	loginChallengeTTL = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+// EnrollTwoFactor starts TOTP enrollment and returns the secret together with
+// an otpauth:// URI for authenticator apps. It stays inactive until
+// ConfirmTwoFactor receives a valid code.
+func (s *Service) EnrollTwoFactor(userID string) (secret, uri string, err error) {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if u.TwoFactor.Enabled {
+		return "", "", fmt.Errorf("%w: two-factor authentication is already enabled", ErrValidation)
+	}
+
+	secret, err = auth.NewTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	u.TwoFactor = TwoFactor{Secret: secret}
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return "", "", err
+	}
+	return secret, auth.TOTPURI(totpIssuer, u.Email, secret), nil
+}
+
+// ConfirmTwoFactor activates a pending enrollment and returns the recovery
+// codes. They are only ever shown here; just their hashes are kept.
+func (s *Service) ConfirmTwoFactor(userID, code string) ([]string, error) {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.TwoFactor.Enabled || u.TwoFactor.Secret == "" {
+		return nil, fmt.Errorf("%w: no pending two-factor enrollment", ErrValidation)
+	}
+	step, ok := auth.ValidateTOTP(u.TwoFactor.Secret, strings.TrimSpace(code), s.now(), u.TwoFactor.LastStep)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid code", ErrValidation)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	u.TwoFactor.Enabled = true
+	u.TwoFactor.LastStep = step
+	u.TwoFactor.RecoveryCodes = hashes
+	u.UpdatedAt = s.now()
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) DisableTwoFactor(userID string, proof Reauth) error {
+	u, err := s.repo.ByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmIdentity(u, proof); err != nil {
+		return err
+	}
+	u.TwoFactor = TwoFactor{}
+	u.UpdatedAt = s.now()
+	return s.repo.Update(u)
+}
+
+// useSecondFactor reports whether code is a current TOTP code or an unused
+// recovery code of u, and records its use on u. The caller saves u.
+func (s *Service) useSecondFactor(u *User, code string) bool {
+	code = strings.TrimSpace(code)
+	if step, ok := auth.ValidateTOTP(u.TwoFactor.Secret, code, s.now(), u.TwoFactor.LastStep); ok {
+		u.TwoFactor.LastStep = step
+		return true
+	}
+	if i := matchRecoveryCode(u.TwoFactor.RecoveryCodes, code); i >= 0 {
+		u.TwoFactor.RecoveryCodes = append(u.TwoFactor.RecoveryCodes[:i:i], u.TwoFactor.RecoveryCodes[i+1:]...)
+		return true
+	}
+	return false
+}
+
+// NewLoginChallenge is issued instead of a session when the password was
+// right but the account has two-factor enabled.
+func (s *Service) NewLoginChallenge(userID string) (string, error) {
+	return s.issueToken(userID, PurposeLoginChallenge, loginChallengeTTL)
+}
+
+// CompleteLoginChallenge accepts either a current TOTP code or an unused
+// recovery code for a login made from ip. Wrong codes count as failed
+// logins of the account; once they lock it out the challenge is dropped,
+// so the password has to be entered again after the lockout.
+func (s *Service) CompleteLoginChallenge(challenge, code, ip string) (*User, error) {
+	s.twoFactorMu.Lock()
+	defer s.twoFactorMu.Unlock()
+
+	t, err := s.tokens.ByHash(hashToken(challenge))
+	if err != nil || t.Purpose != PurposeLoginChallenge || s.now().After(t.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	u, err := s.repo.ByID(t.UserID)
+	if err != nil || !u.TwoFactor.Enabled {
+		return nil, ErrInvalidToken
+	}
+	if err := s.checkLockout(u.Email, ip); err != nil {
+		return nil, err
+	}
+
+	if !s.useSecondFactor(u, code) {
+		wait, err := s.failLogin(u.Email, ip)
+		if err != nil {
+			return nil, err
+		}
+		if wait > 0 {
+			if err := s.tokens.DeleteByUser(u.ID, PurposeLoginChallenge); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrUnauthorized
+	}
+
+	if err := s.repo.Update(u); err != nil {
+		return nil, err
+	}
+	if err := s.succeedLogin(u.Email); err != nil {
+		return nil, err
+	}
+	if err := s.tokens.DeleteByUser(u.ID, PurposeLoginChallenge); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// newRecoveryCodes returns codes formatted as xxxxx-xxxxx and their hashes.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for range recoveryCodeCount {
+		var b [5]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		raw := hex.EncodeToString(b[:])
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(code))
+	}
+	return codes, hashes, nil
+}
+
+func matchRecoveryCode(hashes []string, code string) int {
+	h := hashToken(strings.ToLower(code))
+	for i, stored := range hashes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(h)) == 1 {
+			return i
+		}
+	}
+	return -1
+}
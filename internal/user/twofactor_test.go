@@ -0,0 +1,163 @@
+package user_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/auth"
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+// twoFactorAccount returns a service with a login lockout and an account
+// with two-factor enabled, together with its TOTP secret and recovery codes.
+// The service reads the time from *now.
+func twoFactorAccount(t *testing.T, now *time.Time) (*user.Service, string, []string) {
+	t.Helper()
+	svc := user.NewService(user.NewRepository(), nil, func() time.Time { return *now }, nil)
+	svc.SetLoginThrottle(auth.NewLoginGuard(nil, auth.DefaultLockoutOptions()))
+	u, err := svc.Register("Ana", "ana@example.com", "secret123", "customer")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	secret, _, err := svc.EnrollTwoFactor(u.ID)
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor: %v", err)
+	}
+	code, _ := auth.TOTPCode(secret, *now)
+	recovery, err := svc.ConfirmTwoFactor(u.ID, code)
+	if err != nil {
+		t.Fatalf("ConfirmTwoFactor: %v", err)
+	}
+	// later steps may be used from here on
+	*now = now.Add(90 * time.Second)
+	return svc, secret, recovery
+}
+
+// challenge logs in with the password and returns the second-step challenge.
+func challenge(t *testing.T, svc *user.Service) string {
+	t.Helper()
+	u, err := svc.Authenticate("ana@example.com", "secret123", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	c, err := svc.NewLoginChallenge(u.ID)
+	if err != nil {
+		t.Fatalf("NewLoginChallenge: %v", err)
+	}
+	return c
+}
+
+func TestLoginChallengeTOTP(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, secret, _ := twoFactorAccount(t, &now)
+
+	tests := []struct {
+		name   string
+		offset time.Duration // of the code from now
+		ok     bool
+	}{
+		{"TwoStepsBehind", -60 * time.Second, false},
+		{"TwoStepsAhead", 60 * time.Second, false},
+		{"OneStepBehind", -30 * time.Second, true},
+		{"Current", 0, true},
+		{"OneStepAhead", 30 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := auth.TOTPCode(secret, now.Add(tt.offset))
+			_, err := svc.CompleteLoginChallenge(challenge(t, svc), code, "10.0.0.1")
+			if tt.ok && err != nil {
+				t.Fatalf("CompleteLoginChallenge: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, user.ErrUnauthorized) {
+				t.Fatalf("CompleteLoginChallenge = %v, want ErrUnauthorized", err)
+			}
+		})
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		now = now.Add(90 * time.Second)
+		code, _ := auth.TOTPCode(secret, now)
+		if _, err := svc.CompleteLoginChallenge(challenge(t, svc), code, "10.0.0.1"); err != nil {
+			t.Fatalf("CompleteLoginChallenge: %v", err)
+		}
+		// the same code, and the step before it, stay used
+		previous, _ := auth.TOTPCode(secret, now.Add(-30*time.Second))
+		for _, c := range []string{code, previous} {
+			if _, err := svc.CompleteLoginChallenge(challenge(t, svc), c, "10.0.0.1"); !errors.Is(err, user.ErrUnauthorized) {
+				t.Fatalf("replayed code: CompleteLoginChallenge = %v, want ErrUnauthorized", err)
+			}
+		}
+	})
+}
+
+func TestLoginChallengeRecoveryCodeIsSingleUse(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, _, recovery := twoFactorAccount(t, &now)
+
+	if _, err := svc.CompleteLoginChallenge(challenge(t, svc), recovery[0], "10.0.0.1"); err != nil {
+		t.Fatalf("CompleteLoginChallenge: %v", err)
+	}
+	if _, err := svc.CompleteLoginChallenge(challenge(t, svc), recovery[0], "10.0.0.1"); !errors.Is(err, user.ErrUnauthorized) {
+		t.Fatalf("reused recovery code: CompleteLoginChallenge = %v, want ErrUnauthorized", err)
+	}
+	if _, err := svc.CompleteLoginChallenge(challenge(t, svc), recovery[1], "10.0.0.1"); err != nil {
+		t.Fatalf("CompleteLoginChallenge: %v", err)
+	}
+}
+
+func TestLoginChallengeAttemptLimit(t *testing.T) {
+	t.Run("NewChallengeKeepsCount", func(t *testing.T) {
+		now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+		svc, secret, _ := twoFactorAccount(t, &now)
+
+		c := challenge(t, svc)
+		for range 4 {
+			if _, err := svc.CompleteLoginChallenge(c, "000000", "10.0.0.1"); !errors.Is(err, user.ErrUnauthorized) {
+				t.Fatalf("CompleteLoginChallenge = %v, want ErrUnauthorized", err)
+			}
+		}
+		// the right password must not clear the wrong codes
+		c = challenge(t, svc)
+		if _, err := svc.CompleteLoginChallenge(c, "000000", "10.0.0.1"); !errors.Is(err, user.ErrUnauthorized) {
+			t.Fatalf("CompleteLoginChallenge = %v, want ErrUnauthorized", err)
+		}
+
+		code, _ := auth.TOTPCode(secret, now)
+		if _, err := svc.CompleteLoginChallenge(c, code, "10.0.0.1"); !errors.Is(err, user.ErrInvalidToken) {
+			t.Fatalf("CompleteLoginChallenge after lockout = %v, want ErrInvalidToken", err)
+		}
+		var retry *user.RetryAfterError
+		if _, err := svc.Authenticate("ana@example.com", "secret123", "10.0.0.1"); !errors.As(err, &retry) {
+			t.Fatalf("Authenticate after lockout = %v, want *RetryAfterError", err)
+		}
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+		svc, _, _ := twoFactorAccount(t, &now)
+		c := challenge(t, svc)
+
+		var (
+			mu      sync.Mutex
+			guesses int
+			wg      sync.WaitGroup
+		)
+		for range 50 {
+			wg.Go(func() {
+				_, err := svc.CompleteLoginChallenge(c, "000000", "10.0.0.1")
+				if errors.Is(err, user.ErrUnauthorized) {
+					mu.Lock()
+					guesses++
+					mu.Unlock()
+				}
+			})
+		}
+		wg.Wait()
+		if guesses != 5 {
+			t.Fatalf("%d codes were checked, want 5", guesses)
+		}
+	})
+}
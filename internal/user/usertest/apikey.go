@@ -0,0 +1,136 @@
+package usertest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+// TestAPIKeyRepository runs the behavior every user.APIKeyRepository must
+// share with the in-memory implementation. newRepo must return an empty
+// repository on each call.
+func TestAPIKeyRepository(t *testing.T, newRepo func() user.APIKeyRepository) {
+	t.Helper()
+
+	t.Run("CreateAndFind", func(t *testing.T) {
+		r := newRepo()
+		k := sampleKey("k1", "u1", "aaaa")
+		exp := k.CreatedAt.Add(24 * time.Hour)
+		k.ExpiresAt = &exp
+		mustCreateKey(t, r, k)
+
+		got, err := r.ByPrefix("aaaa")
+		if err != nil {
+			t.Fatalf("ByPrefix: %v", err)
+		}
+		assertKeyEqual(t, got, k)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo()
+		if _, err := r.ByPrefix("missing"); !errors.Is(err, user.ErrAPIKeyNotFound) {
+			t.Fatalf("ByPrefix: got %v, want ErrAPIKeyNotFound", err)
+		}
+		if err := r.Update(sampleKey("missing", "u1", "bbbb")); !errors.Is(err, user.ErrAPIKeyNotFound) {
+			t.Fatalf("Update: got %v, want ErrAPIKeyNotFound", err)
+		}
+	})
+
+	t.Run("ListByUserOldestFirst", func(t *testing.T) {
+		r := newRepo()
+		second := sampleKey("k2", "u1", "bbbb")
+		second.CreatedAt = second.CreatedAt.Add(time.Minute)
+		mustCreateKey(t, r, second)
+		mustCreateKey(t, r, sampleKey("k1", "u1", "aaaa"))
+		mustCreateKey(t, r, sampleKey("k3", "u2", "cccc"))
+
+		list, err := r.ListByUser("u1")
+		if err != nil {
+			t.Fatalf("ListByUser: %v", err)
+		}
+		if len(list) != 2 || list[0].ID != "k1" || list[1].ID != "k2" {
+			t.Fatalf("ListByUser: got %+v, want k1, k2", list)
+		}
+	})
+
+	t.Run("UpdateLastUsed", func(t *testing.T) {
+		r := newRepo()
+		k := sampleKey("k1", "u1", "aaaa")
+		mustCreateKey(t, r, k)
+
+		used := k.CreatedAt.Add(time.Hour)
+		k.LastUsedAt = &used
+		if err := r.Update(k); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, err := r.ByPrefix("aaaa")
+		if err != nil {
+			t.Fatalf("ByPrefix: %v", err)
+		}
+		assertKeyEqual(t, got, k)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		r := newRepo()
+		mustCreateKey(t, r, sampleKey("k1", "u1", "aaaa"))
+		mustCreateKey(t, r, sampleKey("k2", "u1", "bbbb"))
+		mustCreateKey(t, r, sampleKey("k3", "u2", "cccc"))
+
+		if err := r.Delete("k1"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := r.ByPrefix("aaaa"); !errors.Is(err, user.ErrAPIKeyNotFound) {
+			t.Fatalf("ByPrefix after Delete: got %v, want ErrAPIKeyNotFound", err)
+		}
+		if err := r.DeleteByUser("u1"); err != nil {
+			t.Fatalf("DeleteByUser: %v", err)
+		}
+		if list, _ := r.ListByUser("u1"); len(list) != 0 {
+			t.Fatalf("ListByUser after DeleteByUser: got %d keys, want 0", len(list))
+		}
+		if _, err := r.ByPrefix("cccc"); err != nil {
+			t.Fatalf("DeleteByUser removed another user's key: %v", err)
+		}
+	})
+}
+
+func sampleKey(id, userID, prefix string) *user.APIKey {
+	return &user.APIKey{
+		ID:         id,
+		UserID:     userID,
+		Name:       "sync",
+		Prefix:     prefix,
+		SecretHash: "hash-" + prefix,
+		Scopes:     []user.Scope{user.ScopeOrdersRead, user.ScopePostingsWrite},
+		CreatedAt:  time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func mustCreateKey(t *testing.T, r user.APIKeyRepository, k *user.APIKey) {
+	t.Helper()
+	if err := r.Create(k); err != nil {
+		t.Fatalf("Create %s: %v", k.ID, err)
+	}
+}
+
+func assertKeyEqual(t *testing.T, got, want *user.APIKey) {
+	t.Helper()
+	if got.ID != want.ID || got.UserID != want.UserID || got.Name != want.Name || got.Prefix != want.Prefix ||
+		got.SecretHash != want.SecretHash || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if !slices.Equal(got.Scopes, want.Scopes) {
+		t.Fatalf("scopes: got %v, want %v", got.Scopes, want.Scopes)
+	}
+	for _, tm := range []struct {
+		name      string
+		got, want *time.Time
+	}{{"expiresAt", got.ExpiresAt, want.ExpiresAt}, {"lastUsedAt", got.LastUsedAt, want.LastUsedAt}} {
+		if (tm.got == nil) != (tm.want == nil) || (tm.got != nil && !tm.got.Equal(*tm.want)) {
+			t.Fatalf("%s: got %v, want %v", tm.name, tm.got, tm.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package usertest provides a conformance suite for user.Repository
+// implementations.
+package usertest
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Gab-Mello/service-finder/internal/ports"
+	"github.com/Gab-Mello/service-finder/internal/user"
+)
+
+// TestRepository runs the behavior every user.Repository must share with the
+// in-memory implementation. newRepo must return an empty repository on each
+// call.
+func TestRepository(t *testing.T, newRepo func() user.Repository) {
+	t.Helper()
+
+	t.Run("CreateAndFind", func(t *testing.T) {
+		r := newRepo()
+		u := sample("u1", "ana@example.com")
+		if err := r.Create(u); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		byID, err := r.ByID("u1")
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		assertEqual(t, byID, u)
+
+		byEmail, err := r.ByEmail("ana@example.com")
+		if err != nil {
+			t.Fatalf("ByEmail: %v", err)
+		}
+		assertEqual(t, byEmail, u)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo()
+		if _, err := r.ByID("missing"); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("ByID: got %v, want ErrNotFound", err)
+		}
+		if _, err := r.ByEmail("missing@example.com"); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("ByEmail: got %v, want ErrNotFound", err)
+		}
+		if err := r.Update(sample("missing", "missing@example.com")); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("Update: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("CreateDuplicateEmail", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("u1", "ana@example.com"))
+		if err := r.Create(sample("u2", "ana@example.com")); !errors.Is(err, user.ErrEmailTaken) {
+			t.Fatalf("Create: got %v, want ErrEmailTaken", err)
+		}
+		if _, err := r.ByID("u2"); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("rejected user was stored: %v", err)
+		}
+	})
+
+	t.Run("UpdateFields", func(t *testing.T) {
+		r := newRepo()
+		u := sample("u1", "ana@example.com")
+		mustCreate(t, r, u)
+
+		u.Name = "Ana Maria"
+		u.PasswordHash = "other-hash"
+		u.Roles = []user.Role{user.RoleCustomer, user.RoleProvider}
+		u.Provider = &user.ProviderProfile{
+			Bio: "bio", Phone: "11 99999-0000", Expertise: "eletricista", City: "Recife", District: "Boa Viagem",
+		}
+		u.Customer = &user.CustomerProfile{Phone: "11 98888-0000", Addresses: []ports.Address{{
+			ID: "a1", Label: "Casa", Street: "Rua das Flores", Number: "10", District: "Boa Viagem",
+			City: "Recife", PostalCode: "51020-000", Notes: "portão azul",
+		}}}
+		until := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+		u.Suspension = &user.Suspension{Reason: "spam", By: "admin1", At: u.UpdatedAt, Until: &until}
+		u.ErasureAlias = "deleted-1"
+		u.UpdatedAt = u.UpdatedAt.Add(time.Hour)
+		if err := r.Update(u); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := r.ByID("u1")
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		assertEqual(t, got, u)
+	})
+
+	t.Run("UpdateEmailMovesIndex", func(t *testing.T) {
+		r := newRepo()
+		u := sample("u1", "ana@example.com")
+		mustCreate(t, r, u)
+
+		u.Email = "ana.maria@example.com"
+		if err := r.Update(u); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if _, err := r.ByEmail("ana@example.com"); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("old email still indexed: %v", err)
+		}
+		got, err := r.ByEmail("ana.maria@example.com")
+		if err != nil {
+			t.Fatalf("ByEmail new: %v", err)
+		}
+		if got.ID != "u1" {
+			t.Fatalf("ByEmail new: got ID %q, want u1", got.ID)
+		}
+
+		// the released address can be registered again
+		mustCreate(t, r, sample("u2", "ana@example.com"))
+	})
+
+	t.Run("UpdateEmailTaken", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("u1", "ana@example.com"))
+		mustCreate(t, r, sample("u2", "bia@example.com"))
+
+		u := sample("u2", "ana@example.com")
+		u.Name = "changed"
+		if err := r.Update(u); !errors.Is(err, user.ErrEmailTaken) {
+			t.Fatalf("Update: got %v, want ErrEmailTaken", err)
+		}
+
+		got, err := r.ByID("u2")
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		if got.Email != "bia@example.com" || got.Name == "changed" {
+			t.Fatalf("rejected update was applied: %+v", got)
+		}
+		owner, err := r.ByEmail("ana@example.com")
+		if err != nil || owner.ID != "u1" {
+			t.Fatalf("email index corrupted: %+v, %v", owner, err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		r := newRepo()
+		mustCreate(t, r, sample("u1", "ana@example.com"))
+
+		if err := r.Delete("u1"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := r.ByID("u1"); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("ByID after Delete: got %v, want ErrNotFound", err)
+		}
+		if _, err := r.ByEmail("ana@example.com"); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("ByEmail after Delete: got %v, want ErrNotFound", err)
+		}
+		if err := r.Delete("u1"); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("second Delete: got %v, want ErrNotFound", err)
+		}
+		// the address is free again
+		mustCreate(t, r, sample("u2", "ana@example.com"))
+	})
+
+	t.Run("ReturnsCopies", func(t *testing.T) {
+		r := newRepo()
+		u := sample("u1", "ana@example.com")
+		mustCreate(t, r, u)
+		u.Name = "mutated after create"
+
+		got, err := r.ByID("u1")
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		if got.Name != "Ana" {
+			t.Fatalf("Create kept a reference to the caller's value: %q", got.Name)
+		}
+		got.Name = "mutated after read"
+
+		again, _ := r.ByID("u1")
+		if again.Name != "Ana" {
+			t.Fatalf("ByID returned a reference to stored state: %q", again.Name)
+		}
+	})
+}
+
+func sample(id, email string) *user.User {
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	return &user.User{
+		ID:           id,
+		Name:         "Ana",
+		Email:        email,
+		PasswordHash: "hash",
+		Role:         user.RoleCustomer,
+		Roles:        []user.Role{user.RoleCustomer},
+		CreatedAt:    at,
+		UpdatedAt:    at,
+	}
+}
+
+func mustCreate(t *testing.T, r user.Repository, u *user.User) {
+	t.Helper()
+	if err := r.Create(u); err != nil {
+		t.Fatalf("Create %s: %v", u.ID, err)
+	}
+}
+
+func assertEqual(t *testing.T, got, want *user.User) {
+	t.Helper()
+	if got.ID != want.ID || got.Name != want.Name || got.Email != want.Email ||
+		got.PasswordHash != want.PasswordHash || got.Role != want.Role || got.ErasureAlias != want.ErasureAlias ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if !slices.Equal(got.Capabilities(), want.Capabilities()) {
+		t.Fatalf("roles: got %v, want %v", got.Capabilities(), want.Capabilities())
+	}
+	switch {
+	case got.Provider == nil && want.Provider == nil:
+	case got.Provider == nil || want.Provider == nil || *got.Provider != *want.Provider:
+		t.Fatalf("provider: got %+v, want %+v", got.Provider, want.Provider)
+	}
+	switch g, w := got.Suspension, want.Suspension; {
+	case g == nil && w == nil:
+	case g == nil || w == nil || g.Reason != w.Reason || g.By != w.By || !g.At.Equal(w.At) ||
+		(g.Until == nil) != (w.Until == nil) || (g.Until != nil && !g.Until.Equal(*w.Until)):
+		t.Fatalf("suspension: got %+v, want %+v", g, w)
+	}
+	if !reflect.DeepEqual(got.Customer, want.Customer) {
+		t.Fatalf("customer: got %+v, want %+v", got.Customer, want.Customer)
+	}
+}